@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/reduan2660/swapenv/internal/cmd_exec"
+	"github.com/reduan2660/swapenv/internal/cmd_setter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <env> -- <command> [args...]",
+	Short: "Run a command with an environment injected, without touching .env",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+
+		err := cmd_exec.Exec(args[0], args[1:], cmd_setter.ResolveOptions{
+			SkipCommon: viper.GetBool("skip-common"),
+			Version:    viper.GetString("version"),
+			Raw:        viper.GetBool("raw"),
+		}, viper.GetString("yes-i-mean"))
+
+		// the child already reported its failure, only pass the code through
+		var exitErr *cmd_exec.ExitError
+		if errors.As(err, &exitErr) {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+	execCmd.Flags().Bool("skip-common", false, "dont merge inherited envs (common by default)")
+	execCmd.Flags().String("version", "", "use specific version")
+	execCmd.Flags().Bool("raw", false, "don't expand ${VAR} references in values")
+	execCmd.Flags().String("yes-i-mean", "", "confirm running with a protected env, e.g. --yes-i-mean prod")
+}
+
+func GetExecCmd() *cobra.Command {
+	return execCmd
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/reduan2660/swapenv/internal/cmd_setter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// expireCmd is the watcher started by `swapenv to --for`, it waits for the
+// temporary swap to run out and reverts it.
+var expireCmd = &cobra.Command{
+	Use:    "expire",
+	Short:  "Revert a temporary swap when it expires",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+		return cmd_setter.ExpireLease(viper.GetString("project"), viper.GetInt64("at"))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(expireCmd)
+	expireCmd.Flags().String("project", "", "project whose temporary swap to revert")
+	expireCmd.Flags().Int64("at", 0, "unix time the temporary swap expires at")
+}
+
+func GetExpireCmd() *cobra.Command {
+	return expireCmd
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/reduan2660/swapenv/internal/cmd_explain"
+	"github.com/reduan2660/swapenv/internal/cmd_setter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <env>",
+	Short: "Show which env each key of an environment is inherited from",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+		return cmd_explain.Explain(args[0], cmd_explain.ExplainOptions{
+			ResolveOptions: cmd_setter.ResolveOptions{
+				Version: viper.GetString("version"),
+				Raw:     viper.GetBool("raw"),
+			},
+			Reveal:    viper.GetBool("reveal"),
+			Confirmed: viper.GetString("yes-i-mean"),
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+	explainCmd.Flags().String("version", "", "use specific version")
+	explainCmd.Flags().Bool("raw", false, "don't expand ${VAR} references in values")
+	explainCmd.Flags().Bool("reveal", false, "show values instead of masking them")
+	explainCmd.Flags().String("yes-i-mean", "", "confirm revealing a protected env, e.g. --yes-i-mean prod")
+}
+
+func GetExplainCmd() *cobra.Command {
+	return explainCmd
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/cmd_export"
+	"github.com/reduan2660/swapenv/internal/cmd_setter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export an environment in another format (json, yaml, shell, k8s, ...)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+
+		return cmd_export.Export(cmd_export.ExportOptions{
+			ResolveOptions: cmd_setter.ResolveOptions{
+				SkipCommon: viper.GetBool("skip-common"),
+				Version:    viper.GetString("version"),
+				Raw:        viper.GetBool("raw"),
+			},
+			Env:       viper.GetString("env"),
+			Format:    viper.GetString("format"),
+			Output:    viper.GetString("output"),
+			Name:      viper.GetString("name"),
+			Confirmed: viper.GetString("yes-i-mean"),
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().String("env", "", "environment to export")
+	exportCmd.Flags().String("format", "json", fmt.Sprintf("output format (%s)", strings.Join(cmd_export.FormatNames, "|")))
+	exportCmd.Flags().StringP("output", "o", "", "write to file instead of stdout")
+	exportCmd.Flags().String("name", "", "resource name for k8s formats (default: <project>-<env>)")
+	exportCmd.Flags().String("version", "", "use specific version")
+	exportCmd.Flags().Bool("skip-common", false, "dont merge inherited envs (common by default)")
+	exportCmd.Flags().Bool("raw", false, "don't expand ${VAR} references in values")
+	exportCmd.Flags().String("yes-i-mean", "", "confirm exporting a protected env, e.g. --yes-i-mean prod")
+}
+
+func GetExportCmd() *cobra.Command {
+	return exportCmd
+}
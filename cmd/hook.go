@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"github.com/reduan2660/swapenv/internal/cmd_hook"
+	"github.com/spf13/cobra"
+)
+
+var hookCmd = &cobra.Command{
+	Use:       "hook <bash|zsh|fish>",
+	Short:     "Print a shell hook that exports the project env on cd",
+	Long:      "Print a shell hook that exports the project env on cd.\n\nAdd to your shell config, e.g.: eval \"$(swapenv hook bash)\"\nProjects are only exported after `swapenv hook allow`.",
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: cmd_hook.Shells,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd_hook.Hook(args[0])
+	},
+}
+
+var hookAllowCmd = &cobra.Command{
+	Use:   "allow",
+	Short: "Allow the hook to auto-export the current project",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd_hook.Allow(true)
+	},
+}
+
+var hookDenyCmd = &cobra.Command{
+	Use:   "deny",
+	Short: "Stop the hook from auto-exporting the current project",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd_hook.Allow(false)
+	},
+}
+
+var hookExportCmd = &cobra.Command{
+	Use:    "export <bash|zsh|fish>",
+	Short:  "Print shell code to sync the env with the current directory (called by the hook)",
+	Args:   cobra.ExactArgs(1),
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd_hook.Export(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(hookCmd)
+	hookCmd.AddCommand(hookAllowCmd)
+	hookCmd.AddCommand(hookDenyCmd)
+	hookCmd.AddCommand(hookExportCmd)
+}
+
+func GetHookCmd() *cobra.Command {
+	return hookCmd
+}
+
+func GetHookAllowCmd() *cobra.Command {
+	return hookAllowCmd
+}
+
+func GetHookDenyCmd() *cobra.Command {
+	return hookDenyCmd
+}
+
+func GetHookExportCmd() *cobra.Command {
+	return hookExportCmd
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"github.com/reduan2660/swapenv/internal/cmd_hooks"
+	"github.com/spf13/cobra"
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Show the pre_swap and post_swap hooks of the current project and whether they may run",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd_hooks.List()
+	},
+}
+
+var hooksApproveCmd = &cobra.Command{
+	Use:   "approve",
+	Short: "Let swaps run the hooks in the project's .swapenv.yaml as they are now",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd_hooks.Approve(true)
+	},
+}
+
+var hooksRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Stop swaps from running the hooks in the project's .swapenv.yaml",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd_hooks.Approve(false)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(hooksCmd)
+	hooksCmd.AddCommand(hooksApproveCmd)
+	hooksCmd.AddCommand(hooksRevokeCmd)
+}
+
+func GetHooksCmd() *cobra.Command {
+	return hooksCmd
+}
+
+func GetHooksApproveCmd() *cobra.Command {
+	return hooksApproveCmd
+}
+
+func GetHooksRevokeCmd() *cobra.Command {
+	return hooksRevokeCmd
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/reduan2660/swapenv/internal/cmd_import"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import an environment from json, yaml, docker-compose or kubernetes files",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+
+		return cmd_import.Import(args[0], cmd_import.ImportOptions{
+			As:      viper.GetString("as"),
+			Format:  viper.GetString("format"),
+			Service: viper.GetString("service"),
+			Replace: viper.GetBool("replace"),
+			Message: viper.GetString("message"),
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().String("as", "", "environment name to import as")
+	importCmd.Flags().String("format", "", "input format (json|yaml|compose|k8s), detected from the file by default")
+	importCmd.Flags().String("service", "", "compose service to import (default: the only one with an environment)")
+	importCmd.Flags().Bool("replace", false, "Replace existing instead of fast forwarding")
+	importCmd.Flags().StringP("message", "m", "", "message to record on the new version")
+}
+
+func GetImportCmd() *cobra.Command {
+	return importCmd
+}
@@ -10,15 +10,20 @@ import (
 )
 
 var loadCmd = &cobra.Command{
-	Use:   "load",
+	Use:   "load [file...]",
 	Short: "Loads all the environment files",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := viper.BindPFlags(cmd.Flags()); err != nil {
 			return err
 		}
-		envName := viper.GetString("env")
-		replace := viper.GetBool("replace")
-		return cmd_loader.Load(envName, replace)
+		return cmd_loader.Load(cmd_loader.LoadOptions{
+			Env:     viper.GetString("env"),
+			Paths:   args,
+			As:      viper.GetString("as"),
+			Replace: viper.GetBool("replace"),
+			Keep:    viper.GetBool("keep"),
+			Message: viper.GetString("message"),
+		})
 	},
 }
 
@@ -26,6 +31,9 @@ func init() {
 	rootCmd.AddCommand(loadCmd)
 	loadCmd.Flags().String("env", "*", "Specific environment to load")
 	loadCmd.Flags().Bool("replace", false, "Replace existing instead of fast forwarding")
+	loadCmd.Flags().String("as", "", "environment name for an explicit file")
+	loadCmd.Flags().Bool("keep", false, "keep the source files found through the patterns after loading, explicitly named files are always kept")
+	loadCmd.Flags().StringP("message", "m", "", "message to record on the new version")
 }
 
 func GetLoadCmd() *cobra.Command {
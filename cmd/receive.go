@@ -14,13 +14,14 @@ var receiveCmd = &cobra.Command{
 			return err
 		}
 		serverURL := viper.GetString("server")
-		return cmd_receive.Receive(serverURL)
+		return cmd_receive.Receive(serverURL, viper.GetString("message"))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(receiveCmd)
 	receiveCmd.Flags().String("server", "https://swapenv.sh", "swapenv server URL")
+	receiveCmd.Flags().StringP("message", "m", "", "message to record on the new version")
 }
 
 func GetReceiveCmd() *cobra.Command {
@@ -7,8 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/reduan2660/swapenv/internal/cmd_exec"
 	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/cmd_setter"
 	"github.com/reduan2660/swapenv/internal/filehandler"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -27,12 +30,22 @@ var rootCmd = &cobra.Command{
 	},
 
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		return initializeConfig(cmd)
+		if err := initializeConfig(cmd); err != nil {
+			return err
+		}
+		revertExpired()
+		return nil
 	},
 }
 
 func Execute() {
 	err := rootCmd.Execute()
+
+	var exitErr *cmd_exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Code)
+	}
+
 	if err != nil {
 		os.Exit(1)
 	}
@@ -51,6 +64,7 @@ func initializeConfig(cmd *cobra.Command) error {
 	// defaults
 	viper.SetDefault("max_versions", 5)
 	viper.SetDefault("server", "https://app.swapenv.sh")
+	viper.SetDefault("load_patterns", filehandler.DefaultLoadPatterns)
 
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
@@ -81,6 +95,19 @@ func initializeConfig(cmd *cobra.Command) error {
 	return nil
 }
 
+// revertExpired reverts temporary swaps whose watcher didn't get to it, e.g.
+// because the machine was off. Its output goes to stderr so it never mixes
+// with output meant for other tools (info, hook, export).
+func revertExpired() {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+
+	if err := cmd_setter.RevertExpired(time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: error reverting temporary swap: %v\n", err)
+	}
+}
+
 func showProjectInfo() error {
 	projectName, _, localDirectory, _, _, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
 	if err != nil {
@@ -99,8 +126,13 @@ func showProjectInfo() error {
 
 	if activeEnv == "" {
 		fmt.Printf("no active environment for %s - to list available environments run swapenv ls", projectName)
-	} else {
-		fmt.Printf("active environment: %s", activeEnv)
+		return nil
+	}
+
+	fmt.Printf("active environment: %s", activeEnv)
+	if project, err := filehandler.FindProjectByName(projectName); err == nil && project != nil && project.Lease != nil {
+		remaining := max(time.Until(filehandler.LeaseDeadline(project.Lease)), 0)
+		fmt.Printf(" (reverts to %s in %s)", cmd_setter.LeaseTarget(project.Lease), filehandler.FormatRemaining(remaining))
 	}
 	return nil
 }
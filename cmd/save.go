@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/reduan2660/swapenv/internal/cmd_setter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var saveCmd = &cobra.Command{
+	Use:   "save",
+	Short: "Save edits made to .env as a new version of the active environment",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+		return cmd_setter.Save(cmd_setter.SaveOptions{
+			Message: viper.GetString("message"),
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(saveCmd)
+	saveCmd.Flags().StringP("message", "m", "", "message to record on the new version")
+}
+
+func GetSaveCmd() *cobra.Command {
+	return saveCmd
+}
@@ -19,7 +19,7 @@ var shareCmd = &cobra.Command{
 		envName := viper.GetString("env")
 		version := viper.GetString("version")
 
-		return cmd_share.Share(serverURL, projectName, envName, version)
+		return cmd_share.Share(serverURL, projectName, envName, version, viper.GetString("yes-i-mean"))
 	},
 }
 
@@ -28,6 +28,7 @@ func init() {
 	shareCmd.Flags().String("project", "", "project to share (default: current directory)")
 	shareCmd.Flags().String("env", "", "specific environment to share (default: all)")
 	shareCmd.Flags().String("version", "latest", "version to share")
+	shareCmd.Flags().String("yes-i-mean", "", "confirm sharing protected envs, e.g. --yes-i-mean prod or --yes-i-mean prod,staging")
 }
 
 func GetShareCmd() *cobra.Command {
@@ -18,7 +18,7 @@ var spitCmd = &cobra.Command{
 		}
 		envName := viper.GetString("env")
 		version := viper.GetString("version")
-		return cmd_spit.Spit(envName, version)
+		return cmd_spit.Spit(envName, version, viper.GetString("yes-i-mean"))
 	},
 }
 
@@ -26,6 +26,7 @@ func init() {
 	rootCmd.AddCommand(spitCmd)
 	spitCmd.Flags().String("env", "*", "to spit specific env")
 	spitCmd.Flags().String("version", "", "use specific version")
+	spitCmd.Flags().String("yes-i-mean", "", "confirm spitting protected envs, e.g. --yes-i-mean prod or --yes-i-mean prod,staging")
 }
 
 func GetSpitCmd() *cobra.Command {
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/reduan2660/swapenv/internal/cmd_status"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show how the working .env differs from the active environment",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+		return cmd_status.Status(cmd_status.StatusOptions{
+			Reveal: viper.GetBool("reveal"),
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().Bool("reveal", false, "show values instead of masking them")
+}
+
+func GetStatusCmd() *cobra.Command {
+	return statusCmd
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/reduan2660/swapenv/internal/cmd_store"
+	"github.com/spf13/cobra"
+)
+
+var storeCmd = &cobra.Command{
+	Use:   "store",
+	Short: "Show or change how the local store is encrypted",
+	Long:  "Show or change how the local store is encrypted.\n\nThe key is derived from the file set by key_file (or SWAPENV_KEY_FILE),\nelse from SWAPENV_PASSPHRASE, else from a passphrase prompt.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd_store.Status()
+	},
+}
+
+var storeEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt every version file in the store",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd_store.Encrypt()
+	},
+}
+
+var storeDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt the store back to plaintext",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd_store.Decrypt()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(storeCmd)
+	storeCmd.AddCommand(storeEncryptCmd)
+	storeCmd.AddCommand(storeDecryptCmd)
+}
+
+func GetStoreCmd() *cobra.Command {
+	return storeCmd
+}
+
+func GetStoreEncryptCmd() *cobra.Command {
+	return storeEncryptCmd
+}
+
+func GetStoreDecryptCmd() *cobra.Command {
+	return storeDecryptCmd
+}
@@ -18,20 +18,42 @@ var toCmd = &cobra.Command{
 			return err
 		}
 		envName := args[0]
-		replace := viper.GetBool("replace")
-		skipCommon := viper.GetBool("skip-common")
-		version := viper.GetString("version")
-		nowrap := viper.GetBool("nowrap")
-		return cmd_setter.Set(envName, replace, skipCommon, version, nowrap)
+		return cmd_setter.Set(envName, cmd_setter.SetOptions{
+			ResolveOptions: cmd_setter.ResolveOptions{
+				SkipCommon: viper.GetBool("skip-common"),
+				Version:    viper.GetString("version"),
+				Raw:        viper.GetBool("raw"),
+			},
+			Replace: viper.GetBool("replace"),
+			NoWrap:  viper.GetBool("nowrap"),
+			Force:   viper.GetBool("force"),
+			Save:    viper.GetBool("save"),
+			Managed: viper.GetBool("managed"),
+			NoHooks: viper.GetBool("no-hooks"),
+			DryRun:  viper.GetBool("dry-run"),
+			Reveal:  viper.GetBool("reveal"),
+
+			Confirmed: viper.GetString("yes-i-mean"),
+			For:       viper.GetDuration("for"),
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(toCmd)
 	toCmd.Flags().Bool("replace", false, "to replace the existing .env instead of overwriting")
-	toCmd.Flags().Bool("skip-common", false, "dont append common env variables (if exists)")
+	toCmd.Flags().Bool("skip-common", false, "dont merge inherited envs (common by default)")
 	toCmd.Flags().String("version", "", "use specific version")
 	toCmd.Flags().Bool("nowrap", false, "don't wrap values with special characters in single quotes")
+	toCmd.Flags().Bool("raw", false, "don't expand ${VAR} references in values")
+	toCmd.Flags().Bool("force", false, "overwrite .env even if it was edited since the last swap")
+	toCmd.Flags().Bool("save", false, "save edits made to .env since the last swap before swapping")
+	toCmd.Flags().Bool("managed", false, "only replace a swapenv managed block in .env, keeping everything outside it")
+	toCmd.Flags().Bool("no-hooks", false, "don't run the pre_swap and post_swap hooks")
+	toCmd.Flags().Bool("dry-run", false, "show what would change in .env without writing it")
+	toCmd.Flags().Bool("reveal", false, "show values in the dry run instead of masking them")
+	toCmd.Flags().String("yes-i-mean", "", "confirm swapping to protected envs, e.g. --yes-i-mean prod or --yes-i-mean prod,staging")
+	toCmd.Flags().Duration("for", 0, "swap back to the current env after this long, e.g. --for 30m")
 }
 
 func GetToCmd() *cobra.Command {
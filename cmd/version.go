@@ -6,6 +6,7 @@ import (
 
 	"github.com/reduan2660/swapenv/internal/cmd_version"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var versionCmd = &cobra.Command{
@@ -13,10 +14,15 @@ var versionCmd = &cobra.Command{
 	Short: "Show or switch version",
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+
+		env := viper.GetString("env")
 		if len(args) == 0 {
-			return cmd_version.Show()
+			return cmd_version.Show(env)
 		}
-		return cmd_version.Set(args[0])
+		return cmd_version.Set(args[0], env)
 	},
 }
 
@@ -42,6 +48,10 @@ var versionRollbackCmd = &cobra.Command{
 	Short: "Go back N versions (default 1)",
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+
 		steps := 1
 		if len(args) > 0 {
 			var err error
@@ -50,7 +60,87 @@ var versionRollbackCmd = &cobra.Command{
 				return fmt.Errorf("steps must be a positive integer")
 			}
 		}
-		return cmd_version.Rollback(steps)
+		return cmd_version.Rollback(steps, viper.GetString("env"))
+	},
+}
+
+var versionDiffCmd = &cobra.Command{
+	Use:   "diff <version> <version>",
+	Short: "Show keys added, removed and changed between two versions",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+
+		return cmd_version.Diff(args[0], args[1], cmd_version.DiffOptions{
+			Env:       viper.GetString("env"),
+			Reveal:    viper.GetBool("reveal"),
+			Format:    viper.GetString("format"),
+			Confirmed: viper.GetString("yes-i-mean"),
+		})
+	},
+}
+
+var versionLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show how each version was created",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+
+		return cmd_version.Log(cmd_version.LogOptions{
+			Oneline: viper.GetBool("oneline"),
+		})
+	},
+}
+
+var versionPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete versions the retention policy doesn't keep",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+
+		return cmd_version.Prune(cmd_version.PruneOptions{
+			DryRun: viper.GetBool("dry-run"),
+		})
+	},
+}
+
+var versionRevertCmd = &cobra.Command{
+	Use:   "revert <version>",
+	Short: "Create a new version with the content of an old one",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+
+		return cmd_version.Revert(args[0], cmd_version.RevertOptions{
+			Message: viper.GetString("message"),
+		})
+	},
+}
+
+var versionPickCmd = &cobra.Command{
+	Use:   "pick <version>",
+	Short: "Apply keys or envs of an old version on top of the current one",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+
+		return cmd_version.Pick(args[0], cmd_version.PickOptions{
+			Env:     viper.GetString("env"),
+			Keys:    viper.GetStringSlice("keys"),
+			Message: viper.GetString("message"),
+		})
 	},
 }
 
@@ -59,6 +149,29 @@ func init() {
 	versionCmd.AddCommand(versionLsCmd)
 	versionCmd.AddCommand(versionRenameCmd)
 	versionCmd.AddCommand(versionRollbackCmd)
+	versionCmd.AddCommand(versionDiffCmd)
+	versionCmd.AddCommand(versionLogCmd)
+	versionCmd.AddCommand(versionPruneCmd)
+	versionCmd.AddCommand(versionRevertCmd)
+	versionCmd.AddCommand(versionPickCmd)
+
+	versionCmd.Flags().String("env", "", "show or switch the version of one environment only")
+	versionRollbackCmd.Flags().String("env", "", "roll back one environment through its own changes")
+
+	versionDiffCmd.Flags().String("env", "", "only diff this environment")
+	versionDiffCmd.Flags().Bool("reveal", false, "show values instead of masking them")
+	versionDiffCmd.Flags().String("format", "text", "output format (text|json)")
+	versionDiffCmd.Flags().String("yes-i-mean", "", "confirm revealing protected envs, e.g. --yes-i-mean prod or --yes-i-mean prod,staging")
+
+	versionLogCmd.Flags().Bool("oneline", false, "one line per version")
+
+	versionPruneCmd.Flags().Bool("dry-run", false, "list what would be deleted without deleting")
+
+	versionRevertCmd.Flags().StringP("message", "m", "", "message to record on the new version")
+
+	versionPickCmd.Flags().String("env", "", "only pick from this environment")
+	versionPickCmd.Flags().StringSlice("keys", nil, "keys to pick, e.g. A,B (default: whole environments)")
+	versionPickCmd.Flags().StringP("message", "m", "", "message to record on the new version")
 }
 
 func GetVersionCmd() *cobra.Command {
@@ -76,3 +189,23 @@ func GetVersionRenameCmd() *cobra.Command {
 func GetVersionRollbackCmd() *cobra.Command {
 	return versionRollbackCmd
 }
+
+func GetVersionDiffCmd() *cobra.Command {
+	return versionDiffCmd
+}
+
+func GetVersionLogCmd() *cobra.Command {
+	return versionLogCmd
+}
+
+func GetVersionPruneCmd() *cobra.Command {
+	return versionPruneCmd
+}
+
+func GetVersionRevertCmd() *cobra.Command {
+	return versionRevertCmd
+}
+
+func GetVersionPickCmd() *cobra.Command {
+	return versionPickCmd
+}
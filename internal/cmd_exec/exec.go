@@ -0,0 +1,96 @@
+package cmd_exec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/cmd_setter"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+)
+
+// ExitError carries the child's exit code so the CLI can exit with it.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func Exec(env string, command []string, opts cmd_setter.ResolveOptions, confirmed string) error {
+	projectName, _, localDirectory, _, projectPath, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		return fmt.Errorf("no project under current directory, use swapenv load to initiate")
+	}
+
+	if len(command) == 0 {
+		return fmt.Errorf("no command given")
+	}
+
+	if err := filehandler.ConfirmProtected(localDirectory, []string{env}, confirmed); err != nil {
+		return err
+	}
+
+	envValues, err := cmd_setter.ResolveEnv(projectName, projectPath, env, opts)
+	if err != nil {
+		return err
+	}
+
+	child := exec.Command(command[0], command[1:]...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	// later entries win, so env values override the inherited environment
+	child.Env = os.Environ()
+	for _, ev := range envValues {
+		child.Env = append(child.Env, ev.Key+"="+ev.Val)
+	}
+
+	// the terminal already sends ^C and ^\ to the child, which shares our
+	// process group, so those are caught and dropped here. Signal.Ignore would
+	// be inherited by the child. Signals aimed at swapenv alone are passed on.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("error starting %s: %w", command[0], err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if sig == syscall.SIGTERM || sig == syscall.SIGHUP {
+					child.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err = child.Wait()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return &ExitError{Code: 128 + int(status.Signal())}
+		}
+		return &ExitError{Code: exitErr.ExitCode()}
+	}
+
+	return err
+}
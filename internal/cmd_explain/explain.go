@@ -0,0 +1,64 @@
+package cmd_explain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/cmd_setter"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+)
+
+type ExplainOptions struct {
+	cmd_setter.ResolveOptions
+	Reveal bool // show values instead of masking them
+
+	Confirmed string // protected envs confirmed up front (--yes-i-mean)
+}
+
+// Explain prints the inheritance chain of env and, for each key of the
+// resolved env, the env its value comes from.
+func Explain(env string, opts ExplainOptions) error {
+	projectName, _, localDirectory, _, projectPath, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		fmt.Println("no project under current directory, use swapenv load to initiate.")
+		return nil
+	}
+
+	if opts.Reveal {
+		if err := filehandler.ConfirmProtected(localDirectory, []string{env}, opts.Confirmed); err != nil {
+			return err
+		}
+	}
+
+	resolved, err := cmd_setter.Resolve(projectName, projectPath, env, opts.ResolveOptions)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(strings.Join(resolved.Chain, " → "))
+
+	lines := make([]string, len(resolved.Values))
+	width := 0
+	for i, ev := range resolved.Values {
+		val := ev.Val
+		if !opts.Reveal {
+			val = cmd_loader.MaskValue(val)
+		}
+		lines[i] = fmt.Sprintf("%s=%s", ev.Key, val)
+		width = max(width, len(lines[i]))
+	}
+
+	for i, ev := range resolved.Values {
+		origin := resolved.Origin[ev.Key]
+		if overridden := resolved.Overrides[ev.Key]; len(overridden) > 0 {
+			origin += ", overrides " + strings.Join(overridden, ", ")
+		}
+		fmt.Printf("  %-*s  %s\n", width, lines[i], origin)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package cmd_export
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/cmd_setter"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+)
+
+type ExportOptions struct {
+	cmd_setter.ResolveOptions
+	Env    string
+	Format string
+	Output string // file to write to (default: stdout)
+	Name   string // manifest name for k8s formats
+
+	Confirmed string // protected envs confirmed up front (--yes-i-mean)
+}
+
+func Export(opts ExportOptions) error {
+	projectName, _, localDirectory, _, projectPath, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		return fmt.Errorf("no project under current directory, use swapenv load to initiate")
+	}
+
+	if opts.Env == "" {
+		return fmt.Errorf("--env is required")
+	}
+
+	formatter, ok := formatters[opts.Format]
+	if !ok {
+		return fmt.Errorf("unknown format '%s', available: %s", opts.Format, strings.Join(FormatNames, ", "))
+	}
+
+	if err := filehandler.ConfirmProtected(localDirectory, []string{opts.Env}, opts.Confirmed); err != nil {
+		return err
+	}
+
+	envValues, err := cmd_setter.ResolveEnv(projectName, projectPath, opts.Env, opts.ResolveOptions)
+	if err != nil {
+		return err
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = manifestName(projectName + "-" + opts.Env)
+	}
+
+	content, err := formatter(envValues, name)
+	if err != nil {
+		return err
+	}
+
+	if opts.Output == "" {
+		fmt.Print(content)
+		return nil
+	}
+
+	if err := os.WriteFile(opts.Output, []byte(content), 0600); err != nil {
+		return fmt.Errorf("error writing %s: %w", opts.Output, err)
+	}
+
+	fmt.Printf("exported %s to %s\n", opts.Env, opts.Output)
+	return nil
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// manifestName turns a project/env name into a valid kubernetes resource name
+func manifestName(name string) string {
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
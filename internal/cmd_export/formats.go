@@ -0,0 +1,172 @@
+package cmd_export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+type formatter func(envValues []types.EnvValue, name string) (string, error)
+
+var FormatNames = []string{"json", "yaml", "shell", "docker", "systemd", "github", "k8s-secret", "k8s-configmap"}
+
+var formatters = map[string]formatter{
+	"json":          formatJSON,
+	"yaml":          formatYAML,
+	"shell":         formatShell,
+	"docker":        formatDocker,
+	"systemd":       formatSystemd,
+	"github":        formatGithub,
+	"k8s-secret":    formatK8sSecret,
+	"k8s-configmap": formatK8sConfigMap,
+}
+
+// validKey matches names that are safe to write unquoted as a variable name,
+// the same check the shell hook makes
+var validKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// checkKeys rejects keys that line based formats would write as code or
+// could break the line format with, e.g. X;$(cmd)
+func checkKeys(envValues []types.EnvValue) error {
+	for _, ev := range envValues {
+		if !validKey.MatchString(ev.Key) {
+			return fmt.Errorf("%q is not a valid variable name", ev.Key)
+		}
+	}
+	return nil
+}
+
+// quote renders a JSON string, which is also a valid YAML double-quoted scalar
+func quote(val string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(val)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func formatJSON(envValues []types.EnvValue, _ string) (string, error) {
+	if len(envValues) == 0 {
+		return "{}\n", nil
+	}
+
+	var builder strings.Builder
+	builder.WriteString("{\n")
+	for i, ev := range envValues {
+		builder.WriteString(fmt.Sprintf("  %s: %s", quote(ev.Key), quote(ev.Val)))
+		if i < len(envValues)-1 {
+			builder.WriteString(",")
+		}
+		builder.WriteString("\n")
+	}
+	builder.WriteString("}\n")
+	return builder.String(), nil
+}
+
+func formatYAML(envValues []types.EnvValue, _ string) (string, error) {
+	return yamlMap(envValues, ""), nil
+}
+
+func yamlMap(envValues []types.EnvValue, indent string) string {
+	var builder strings.Builder
+	for _, ev := range envValues {
+		builder.WriteString(fmt.Sprintf("%s%s: %s\n", indent, quote(ev.Key), quote(ev.Val)))
+	}
+	return builder.String()
+}
+
+func formatShell(envValues []types.EnvValue, _ string) (string, error) {
+	if err := checkKeys(envValues); err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	for _, ev := range envValues {
+		val := "'" + strings.ReplaceAll(ev.Val, "'", `'\''`) + "'"
+		builder.WriteString(fmt.Sprintf("export %s=%s\n", ev.Key, val))
+	}
+	return builder.String(), nil
+}
+
+// formatDocker writes a `docker run --env-file` file, which takes values literally
+func formatDocker(envValues []types.EnvValue, _ string) (string, error) {
+	if err := checkKeys(envValues); err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	for _, ev := range envValues {
+		if strings.ContainsAny(ev.Val, "\n\r") {
+			return "", fmt.Errorf("%s: docker env files can't hold multiline values", ev.Key)
+		}
+		builder.WriteString(fmt.Sprintf("%s=%s\n", ev.Key, ev.Val))
+	}
+	return builder.String(), nil
+}
+
+var systemdEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+func formatSystemd(envValues []types.EnvValue, _ string) (string, error) {
+	if err := checkKeys(envValues); err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	for _, ev := range envValues {
+		builder.WriteString(fmt.Sprintf("%s=\"%s\"\n", ev.Key, systemdEscaper.Replace(ev.Val)))
+	}
+	return builder.String(), nil
+}
+
+// formatGithub writes a block for $GITHUB_ENV, using a random heredoc
+// delimiter for multiline values so a value can't end the block early
+func formatGithub(envValues []types.EnvValue, _ string) (string, error) {
+	if err := checkKeys(envValues); err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	for _, ev := range envValues {
+		if !strings.ContainsAny(ev.Val, "\n\r") {
+			builder.WriteString(fmt.Sprintf("%s=%s\n", ev.Key, ev.Val))
+			continue
+		}
+		delimiter := "ghadelimiter_" + uuid.New().String()
+		builder.WriteString(fmt.Sprintf("%s<<%s\n%s\n%s\n", ev.Key, delimiter, ev.Val, delimiter))
+	}
+	return builder.String(), nil
+}
+
+func formatK8sSecret(envValues []types.EnvValue, name string) (string, error) {
+	var builder strings.Builder
+	builder.WriteString("apiVersion: v1\n")
+	builder.WriteString("kind: Secret\n")
+	builder.WriteString("metadata:\n")
+	builder.WriteString(fmt.Sprintf("  name: %s\n", quote(name)))
+	builder.WriteString("type: Opaque\n")
+	builder.WriteString("stringData:\n")
+	builder.WriteString(yamlMap(envValues, "  "))
+	return builder.String(), nil
+}
+
+func formatK8sConfigMap(envValues []types.EnvValue, name string) (string, error) {
+	var builder strings.Builder
+	builder.WriteString("apiVersion: v1\n")
+	builder.WriteString("kind: ConfigMap\n")
+	builder.WriteString("metadata:\n")
+	builder.WriteString(fmt.Sprintf("  name: %s\n", quote(name)))
+	builder.WriteString("data:\n")
+	builder.WriteString(yamlMap(envValues, "  "))
+	return builder.String(), nil
+}
@@ -0,0 +1,224 @@
+package cmd_hook
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/cmd_setter"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+	"github.com/spf13/viper"
+)
+
+// Shell state kept between prompts:
+//   - stampVar: what the last export was computed from, to skip unchanged prompts
+//   - prevVar: the values our exported keys had before, to restore on unload
+const (
+	stampVar = "SWAPENV_STAMP"
+	prevVar  = "SWAPENV_PREV"
+)
+
+var validKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Export prints the shell code that loads the current directory's env and
+// unloads whatever was loaded before. It returns early, without reading any
+// version file, when nothing changed since the last prompt.
+func Export(shell string) error {
+	if !isShell(shell) {
+		return fmt.Errorf("unsupported shell '%s', available: %s", shell, strings.Join(Shells, ", "))
+	}
+
+	// runs on every prompt, an encrypted store is only exported with a key
+	// file or SWAPENV_PASSPHRASE
+	filehandler.DisablePrompt()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	stamp := computeStamp(cwd)
+	if os.Getenv(stampVar) == stamp {
+		return nil
+	}
+
+	prev := decodePrev(os.Getenv(prevVar))
+
+	project, envValues := activeEnv(cwd)
+
+	var out strings.Builder
+	loaded := make(map[string]bool)
+	next := make(map[string]*string)
+
+	for _, ev := range envValues {
+		if !validKey.MatchString(ev.Key) {
+			fmt.Fprintf(os.Stderr, "swapenv: skipping %s, not a valid shell variable name\n", ev.Key)
+			continue
+		}
+
+		if orig, ok := prev[ev.Key]; ok {
+			next[ev.Key] = orig
+		} else if orig, ok := os.LookupEnv(ev.Key); ok {
+			next[ev.Key] = &orig
+		} else {
+			next[ev.Key] = nil
+		}
+
+		loaded[ev.Key] = true
+		out.WriteString(exportLine(shell, ev.Key, ev.Val))
+	}
+
+	prevKeys := make([]string, 0, len(prev))
+	for key := range prev {
+		prevKeys = append(prevKeys, key)
+	}
+	sort.Strings(prevKeys)
+
+	for _, key := range prevKeys {
+		if loaded[key] {
+			continue
+		}
+		if orig := prev[key]; orig != nil {
+			out.WriteString(exportLine(shell, key, *orig))
+		} else {
+			out.WriteString(unsetLine(shell, key))
+		}
+	}
+
+	out.WriteString(exportLine(shell, stampVar, stamp))
+	if len(next) > 0 {
+		out.WriteString(exportLine(shell, prevVar, encodePrev(next)))
+	} else {
+		out.WriteString(unsetLine(shell, prevVar))
+	}
+
+	if len(loaded) > 0 {
+		fmt.Fprintf(os.Stderr, "swapenv: loaded %s (%s)\n", project.CurrentEnv, project.ProjectName)
+	} else if len(prev) > 0 {
+		fmt.Fprintln(os.Stderr, "swapenv: unloaded")
+	}
+
+	fmt.Print(out.String())
+	return nil
+}
+
+// activeEnv resolves the env to export for cwd, or nothing when there's no
+// project, no active env, or the project hasn't been allowed
+func activeEnv(cwd string) (*types.ProjectDir, []types.EnvValue) {
+	project, err := filehandler.FindProjectForPath(cwd)
+	if err != nil || project == nil || project.CurrentEnv == "" || project.CurrentVersion == 0 {
+		return project, nil
+	}
+
+	allowed, err := filehandler.IsAllowed(project.LocalPath, project.ProjectName)
+	if err != nil || !allowed {
+		fmt.Fprintf(os.Stderr, "swapenv: %s is not allowed, run `swapenv hook allow` to auto-export %s\n", project.LocalPath, project.CurrentEnv)
+		return project, nil
+	}
+
+	projectPath, err := filehandler.GetVersionFilePath(project.ProjectName, project.CurrentVersion)
+	if err != nil {
+		return project, nil
+	}
+
+	envValues, err := cmd_setter.ResolveEnv(project.ProjectName, projectPath, project.CurrentEnv, cmd_setter.ResolveOptions{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "swapenv: error loading %s: %v\n", project.CurrentEnv, err)
+		return project, nil
+	}
+
+	return project, envValues
+}
+
+// computeStamp changes whenever the directory, the project map, the allow
+// list or the config changes. Version files are never rewritten, so the map
+// covers them. The config decides inheritance, for the project's
+// .swapenv.yaml every directory up from cwd is checked, the project root
+// isn't known without reading the map.
+func computeStamp(cwd string) string {
+	var parts []string
+	parts = append(parts, cwd)
+
+	for _, getPath := range []func() (string, error){filehandler.GetMapFilePath, filehandler.GetAllowFilePath} {
+		path, err := getPath()
+		if err != nil {
+			path = ""
+		}
+		parts = append(parts, modTime(path))
+	}
+
+	parts = append(parts, modTime(viper.ConfigFileUsed()))
+	for dir := cwd; ; dir = filepath.Dir(dir) {
+		parts = append(parts, modTime(filepath.Join(dir, filehandler.ProjectConfigFile)))
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	return strings.Join(parts, ":")
+}
+
+func modTime(path string) string {
+	var modTime int64
+	if path != "" {
+		if info, err := os.Stat(path); err == nil {
+			modTime = info.ModTime().UnixNano()
+		}
+	}
+	return fmt.Sprint(modTime)
+}
+
+func encodePrev(prev map[string]*string) string {
+	data, _ := json.Marshal(prev)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func decodePrev(encoded string) map[string]*string {
+	prev := make(map[string]*string)
+	if encoded == "" {
+		return prev
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return prev
+	}
+	json.Unmarshal(data, &prev)
+	return prev
+}
+
+func isShell(shell string) bool {
+	for _, s := range Shells {
+		if s == shell {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteFor(shell, val string) string {
+	if shell == "fish" {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(val) + "'"
+	}
+	return "'" + strings.ReplaceAll(val, "'", `'\''`) + "'"
+}
+
+func exportLine(shell, key, val string) string {
+	if shell == "fish" {
+		return fmt.Sprintf("set -gx %s %s;\n", key, quoteFor(shell, val))
+	}
+	return fmt.Sprintf("export %s=%s;\n", key, quoteFor(shell, val))
+}
+
+func unsetLine(shell, key string) string {
+	if shell == "fish" {
+		return fmt.Sprintf("set -e %s;\n", key)
+	}
+	return fmt.Sprintf("unset %s;\n", key)
+}
@@ -0,0 +1,89 @@
+package cmd_hook
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/filehandler"
+)
+
+var Shells = []string{"bash", "zsh", "fish"}
+
+const bashHook = `_swapenv_hook() {
+  local previous_exit_status=$?
+  eval "$(%[1]s hook export bash)"
+  return $previous_exit_status
+}
+if [[ ";${PROMPT_COMMAND[*]:-};" != *";_swapenv_hook;"* ]]; then
+  PROMPT_COMMAND="_swapenv_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`
+
+const zshHook = `_swapenv_hook() {
+  eval "$(%[1]s hook export zsh)"
+}
+typeset -ag precmd_functions
+if (( ! ${precmd_functions[(I)_swapenv_hook]} )); then
+  precmd_functions=(_swapenv_hook $precmd_functions)
+fi
+typeset -ag chpwd_functions
+if (( ! ${chpwd_functions[(I)_swapenv_hook]} )); then
+  chpwd_functions=(_swapenv_hook $chpwd_functions)
+fi
+`
+
+const fishHook = `function __swapenv_export_eval --on-event fish_prompt
+    %[1]s hook export fish | source
+end
+`
+
+// Hook prints the prompt hook that keeps the shell in sync with the project env
+func Hook(shell string) error {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashHook
+	case "zsh":
+		script = zshHook
+	case "fish":
+		script = fishHook
+	default:
+		return fmt.Errorf("unsupported shell '%s', available: %s", shell, strings.Join(Shells, ", "))
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "swapenv"
+	}
+
+	fmt.Printf(script, quoteFor(shell, executable))
+	return nil
+}
+
+// Allow approves the project around the current directory for auto-export
+func Allow(allow bool) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	project, err := filehandler.FindProjectForPath(cwd)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return fmt.Errorf("no project under current directory, use swapenv load to initiate")
+	}
+
+	if err := filehandler.SetAllowed(project.LocalPath, project.ProjectName, allow); err != nil {
+		return err
+	}
+
+	if allow {
+		fmt.Printf("allowed %s (%s)\n", project.ProjectName, project.LocalPath)
+	} else {
+		fmt.Printf("denied %s (%s)\n", project.ProjectName, project.LocalPath)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package cmd_hooks
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+// List prints the hooks in the .swapenv.yaml of the project around the
+// current directory and whether they are approved to run.
+func List() error {
+	project, err := currentProject()
+	if err != nil {
+		return err
+	}
+
+	hooks, err := filehandler.ProjectHooks(project.LocalPath)
+	if err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		fmt.Printf("no hooks in %s\n", filehandler.ProjectConfigFile)
+		return nil
+	}
+
+	printHooks(hooks)
+
+	approved, err := isApproved(project.LocalPath)
+	if err != nil {
+		return err
+	}
+	if approved {
+		fmt.Println("approved")
+	} else {
+		fmt.Println("not approved, run `swapenv hooks approve` to let swaps run them")
+	}
+	return nil
+}
+
+// Approve approves the hooks in the project's .swapenv.yaml as they are now,
+// or revokes the approval.
+func Approve(approve bool) error {
+	project, err := currentProject()
+	if err != nil {
+		return err
+	}
+
+	if !approve {
+		if err := filehandler.SetHooksApproved(project.LocalPath, ""); err != nil {
+			return err
+		}
+		fmt.Printf("revoked the hooks of %s (%s)\n", project.ProjectName, project.LocalPath)
+		return nil
+	}
+
+	hooks, err := filehandler.ProjectHooks(project.LocalPath)
+	if err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		fmt.Printf("no hooks in %s to approve\n", filehandler.ProjectConfigFile)
+		return nil
+	}
+
+	digest, err := filehandler.ProjectHooksDigest(project.LocalPath)
+	if err != nil {
+		return err
+	}
+	if err := filehandler.SetHooksApproved(project.LocalPath, digest); err != nil {
+		return err
+	}
+
+	printHooks(hooks)
+	fmt.Printf("approved the hooks of %s (%s)\n", project.ProjectName, project.LocalPath)
+	return nil
+}
+
+func isApproved(localPath string) (bool, error) {
+	digest, err := filehandler.ProjectHooksDigest(localPath)
+	if err != nil {
+		return false, err
+	}
+	return filehandler.IsHooksApproved(localPath, digest)
+}
+
+func printHooks(hooks map[string][]string) {
+	names := make([]string, 0, len(hooks))
+	for name := range hooks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		fmt.Printf("%s:\n", name)
+		for _, command := range hooks[name] {
+			fmt.Printf("  %s\n", command)
+		}
+	}
+}
+
+func currentProject() (*types.ProjectDir, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := filehandler.FindProjectForPath(cwd)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, fmt.Errorf("no project under current directory, use swapenv load to initiate")
+	}
+	return project, nil
+}
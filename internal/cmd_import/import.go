@@ -0,0 +1,80 @@
+package cmd_import
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+type ImportOptions struct {
+	As      string // env name to store under
+	Format  string // json|yaml|compose|k8s, detected from the file when empty
+	Service string // compose service to read
+	Replace bool
+	Message string // recorded on the new version
+}
+
+func Import(file string, opts ImportOptions) error {
+	if opts.As == "" {
+		return fmt.Errorf("--as is required")
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+
+	format := opts.Format
+	if format == "" {
+		format, err = detectFormat(file, content)
+		if err != nil {
+			return err
+		}
+	}
+
+	var envValues []types.EnvValue
+	switch format {
+	case "json", "yaml":
+		envValues, err = parseFlat(content)
+	case "compose":
+		envValues, err = parseCompose(content, opts.Service)
+	case "k8s":
+		envValues, err = parseK8s(content)
+	default:
+		return fmt.Errorf("unknown format '%s', available: json, yaml, compose, k8s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %w", file, err)
+	}
+
+	if len(envValues) == 0 {
+		fmt.Print("no environment to import")
+		return nil
+	}
+
+	projectName, localOwner, localDirectory, homeDirectory, _, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: false})
+	if err != nil {
+		return err
+	}
+
+	envName := strings.ToLower(opts.As)
+	envs := map[string][]types.EnvValue{envName: envValues}
+
+	storeOpts := cmd_loader.StoreOptions{
+		Replace:   opts.Replace,
+		Operation: types.OpImport,
+		Source:    []string{file},
+		Message:   opts.Message,
+	}
+	version, err := cmd_loader.StoreEnvs(projectName, localOwner, localDirectory, homeDirectory, envs, storeOpts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %s as %s (v%d)\n", filepath.Base(file), envName, version)
+	return nil
+}
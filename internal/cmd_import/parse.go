@@ -0,0 +1,211 @@
+package cmd_import
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/types"
+	"go.yaml.in/yaml/v3"
+)
+
+// detectFormat guesses the format from the extension and, for yaml, the top level keys
+func detectFormat(file string, content []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(file))
+	if ext == ".json" {
+		return "json", nil
+	}
+	if ext != ".yaml" && ext != ".yml" {
+		return "", fmt.Errorf("can't detect format of %s, use --format", file)
+	}
+
+	var root map[string]any
+	if err := yaml.NewDecoder(bytes.NewReader(content)).Decode(&root); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	if _, ok := root["services"]; ok {
+		return "compose", nil
+	}
+	if _, ok := root["kind"]; ok {
+		return "k8s", nil
+	}
+	return "yaml", nil
+}
+
+// parseFlat reads a flat JSON/YAML map of scalars, keeping the file's key order
+func parseFlat(content []byte) ([]types.EnvValue, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	return mappingToEnv(doc.Content[0])
+}
+
+func parseCompose(content []byte, service string) ([]types.EnvValue, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("empty compose file")
+	}
+
+	services := lookup(doc.Content[0], "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("no services found")
+	}
+
+	withEnv := make([]string, 0)
+	environments := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name := services.Content[i].Value
+		if env := lookup(services.Content[i+1], "environment"); env != nil {
+			withEnv = append(withEnv, name)
+			environments[name] = env
+		}
+	}
+
+	if service == "" {
+		if len(withEnv) != 1 {
+			return nil, fmt.Errorf("use --service to pick one of: %s", strings.Join(withEnv, ", "))
+		}
+		service = withEnv[0]
+	}
+
+	env, ok := environments[service]
+	if !ok {
+		return nil, fmt.Errorf("service '%s' has no environment section", service)
+	}
+
+	if env.Kind == yaml.MappingNode {
+		return mappingToEnv(env)
+	}
+
+	if env.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("service '%s': unsupported environment section", service)
+	}
+
+	envValues := make([]types.EnvValue, 0, len(env.Content))
+	for _, item := range env.Content {
+		key, val, found := strings.Cut(item.Value, "=")
+		if !found {
+			// `- KEY` passes the host value through, nothing to store
+			continue
+		}
+		envValues = append(envValues, types.EnvValue{Key: key, Val: val, Order: len(envValues) + 1})
+	}
+	return envValues, nil
+}
+
+// parseK8s reads data and stringData from every Secret and ConfigMap in the file
+func parseK8s(content []byte) ([]types.EnvValue, error) {
+	envValues := make([]types.EnvValue, 0)
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+	found := false
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		root := doc.Content[0]
+
+		kind := lookup(root, "kind")
+		if kind == nil || !slices.Contains([]string{"Secret", "ConfigMap"}, kind.Value) {
+			continue
+		}
+		found = true
+
+		for _, section := range []string{"data", "stringData"} {
+			node := lookup(root, section)
+			if node == nil {
+				continue
+			}
+
+			values, err := mappingToEnv(node)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", kind.Value, section, err)
+			}
+
+			if kind.Value == "Secret" && section == "data" {
+				for i := range values {
+					decoded, err := base64.StdEncoding.DecodeString(values[i].Val)
+					if err != nil {
+						return nil, fmt.Errorf("secret data %s: invalid base64: %w", values[i].Key, err)
+					}
+					values[i].Val = string(decoded)
+				}
+			}
+
+			envValues = upsert(envValues, values)
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no Secret or ConfigMap found")
+	}
+	return envValues, nil
+}
+
+func mappingToEnv(node *yaml.Node) ([]types.EnvValue, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a map of keys to values")
+	}
+
+	envValues := make([]types.EnvValue, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := node.Content[i], node.Content[i+1]
+		if val.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("%s: nested values are not supported", key.Value)
+		}
+
+		value := val.Value
+		if val.Tag == "!!null" {
+			value = ""
+		}
+		envValues = append(envValues, types.EnvValue{Key: key.Value, Val: value, Order: len(envValues) + 1})
+	}
+	return envValues, nil
+}
+
+func lookup(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// upsert overwrites existing keys in place and appends new ones
+func upsert(envValues, values []types.EnvValue) []types.EnvValue {
+	for _, ev := range values {
+		idx := slices.IndexFunc(envValues, func(existing types.EnvValue) bool { return existing.Key == ev.Key })
+		if idx >= 0 {
+			envValues[idx].Val = ev.Val
+			continue
+		}
+		ev.Order = len(envValues) + 1
+		envValues = append(envValues, ev)
+	}
+	return envValues
+}
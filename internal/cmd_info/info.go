@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/reduan2660/swapenv/internal/filehandler"
 )
@@ -14,11 +15,23 @@ type ProjectInfo struct {
 	Envs          []string `json:"envs,omitempty"`
 	Version       int      `json:"version,omitempty"`
 	LatestVersion int      `json:"latest_version,omitempty"`
+	Lease         *Lease   `json:"lease,omitempty"`
+}
+
+// Lease is a temporary swap of the active env, see swapenv to --for.
+type Lease struct {
+	RevertsTo string `json:"reverts_to"`
+	ExpiresAt string `json:"expires_at"`
+	Remaining string `json:"remaining"`
 }
 
 func Info(format string, envOnly bool) error {
 	info := ProjectInfo{}
 
+	// shell prompts call info, the env list is left out rather than asking
+	// for the passphrase of an encrypted store
+	filehandler.DisablePrompt()
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		return outputInfo(info, format, envOnly)
@@ -34,6 +47,15 @@ func Info(format string, envOnly bool) error {
 	info.Version = project.CurrentVersion
 	info.LatestVersion = project.LatestVersion
 
+	if project.Lease != nil {
+		deadline := filehandler.LeaseDeadline(project.Lease)
+		info.Lease = &Lease{
+			RevertsTo: project.Lease.PreviousEnv,
+			ExpiresAt: deadline.Format(time.RFC3339),
+			Remaining: filehandler.FormatRemaining(max(time.Until(deadline), 0)),
+		}
+	}
+
 	version := project.CurrentVersion
 	if version == 0 {
 		version = project.LatestVersion
@@ -64,6 +86,10 @@ func outputInfo(info ProjectInfo, format string, envOnly bool) error {
 		if env == "" {
 			env = "none"
 		}
+		if info.Lease != nil {
+			fmt.Printf("%s:%s (%s left)\n", info.Project, env, info.Lease.Remaining)
+			return nil
+		}
 		fmt.Printf("%s:%s\n", info.Project, env)
 		return nil
 	}
@@ -0,0 +1,71 @@
+package cmd_loader
+
+import (
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+type ChangeKind string
+
+const (
+	KeyAdded   ChangeKind = "added"
+	KeyRemoved ChangeKind = "removed"
+	KeyChanged ChangeKind = "changed"
+)
+
+type EnvChange struct {
+	Key  string     `json:"key"`
+	Kind ChangeKind `json:"change"`
+	Old  string     `json:"old,omitempty"`
+	New  string     `json:"new,omitempty"`
+}
+
+const maskedValue = "********"
+
+// DiffEnv lists the keys added, removed or changed going from one env to the
+// other. Added and changed keys follow the order of to, removed keys come last.
+func DiffEnv(from, to []types.EnvValue) []EnvChange {
+	fromVals := make(map[string]string, len(from))
+	for _, ev := range from {
+		fromVals[ev.Key] = ev.Val
+	}
+
+	toKeys := make(map[string]bool, len(to))
+	changes := make([]EnvChange, 0)
+
+	for _, ev := range to {
+		toKeys[ev.Key] = true
+		old, exists := fromVals[ev.Key]
+		switch {
+		case !exists:
+			changes = append(changes, EnvChange{Key: ev.Key, Kind: KeyAdded, New: ev.Val})
+		case old != ev.Val:
+			changes = append(changes, EnvChange{Key: ev.Key, Kind: KeyChanged, Old: old, New: ev.Val})
+		}
+	}
+
+	for _, ev := range from {
+		if !toKeys[ev.Key] {
+			changes = append(changes, EnvChange{Key: ev.Key, Kind: KeyRemoved, Old: ev.Val})
+		}
+	}
+
+	return changes
+}
+
+// MaskChanges hides the values of changes, keeping whether a value was empty.
+func MaskChanges(changes []EnvChange) []EnvChange {
+	masked := make([]EnvChange, len(changes))
+	for i, change := range changes {
+		change.Old = MaskValue(change.Old)
+		change.New = MaskValue(change.New)
+		masked[i] = change
+	}
+	return masked
+}
+
+func MaskValue(val string) string {
+	if val == "" {
+		return ""
+	}
+	return maskedValue
+}
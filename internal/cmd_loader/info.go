@@ -7,7 +7,6 @@ import (
 	"path/filepath"
 
 	"github.com/reduan2660/swapenv/internal/filehandler"
-	"github.com/reduan2660/swapenv/internal/types"
 )
 
 type GetBasicInfoOptions struct {
@@ -39,34 +38,7 @@ func GetBasicInfo(opts GetBasicInfoOptions) (projectName, localOwner, localDirec
 			return "", "", "", "", "", fmt.Errorf("error getting project name: %w", err)
 		}
 
-		dirs, err := filehandler.ReadProjectDirs()
-		if err != nil {
-			return "", "", "", "", "", fmt.Errorf("error reading project map: %w", err)
-		}
-
-		nameExists := false
-		for _, dir := range dirs {
-			if dir.ProjectName == projectName {
-				nameExists = true
-				break
-			}
-		}
-
-		if nameExists {
-			parentDir := filepath.Base(filepath.Dir(localDirectory))
-			projectName = parentDir + "/" + projectName
-		}
-
-		newProjectDir := types.ProjectDir{
-			ProjectName:    projectName,
-			CurrentEnv:     "",
-			LocalPath:      localDirectory,
-			RemotePath:     "",
-			CurrentVersion: 0,
-			LatestVersion:  0,
-			VersionNames:   make(map[string]string),
-		}
-		if err := filehandler.UpsertProjectDir(newProjectDir); err != nil {
+		if err := filehandler.RegisterProjectDir(localDirectory, projectName); err != nil {
 			return "", "", "", "", "", fmt.Errorf("error adding project to map: %w", err)
 		}
 
@@ -74,6 +46,7 @@ func GetBasicInfo(opts GetBasicInfoOptions) (projectName, localOwner, localDirec
 		if err != nil {
 			return "", "", "", "", "", fmt.Errorf("error fetching new project: %w", err)
 		}
+		projectName = existingProject.ProjectName
 
 	}
 
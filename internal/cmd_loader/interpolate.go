@@ -0,0 +1,157 @@
+package cmd_loader
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+// ExpandEnv resolves ${VAR}, ${VAR:-default} and ${VAR:?error} references
+// against the other values in envValues. References to unknown keys, or to the
+// key itself, expand to an empty string. Literal values are kept as they are,
+// and $${ or \${ stand for a literal ${. The input slice is not modified.
+func ExpandEnv(envValues []types.EnvValue) ([]types.EnvValue, error) {
+	expander := &envExpander{
+		raw:      make(map[string]string, len(envValues)),
+		literal:  make(map[string]bool),
+		resolved: make(map[string]string, len(envValues)),
+	}
+	for _, ev := range envValues {
+		expander.raw[ev.Key] = ev.Val
+		expander.literal[ev.Key] = ev.Literal
+	}
+
+	expanded := make([]types.EnvValue, len(envValues))
+	for i, ev := range envValues {
+		val, err := expander.resolve(ev.Key, nil)
+		if err != nil {
+			return nil, err
+		}
+		ev.Val = val
+		// an escaped ${ must not be expanded again when the value is written out
+		ev.Literal = ev.Literal || strings.Contains(val, "${")
+		expanded[i] = ev
+	}
+
+	return expanded, nil
+}
+
+type envExpander struct {
+	raw      map[string]string
+	literal  map[string]bool
+	resolved map[string]string
+}
+
+func (e *envExpander) resolve(key string, stack []string) (string, error) {
+	if val, ok := e.resolved[key]; ok {
+		return val, nil
+	}
+	if e.literal[key] {
+		return e.raw[key], nil
+	}
+
+	for i, k := range stack {
+		if k == key {
+			cycle := append(stack[i:], key)
+			return "", fmt.Errorf("interpolation cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	val, err := e.expand(key, e.raw[key], append(stack, key))
+	if err != nil {
+		return "", err
+	}
+
+	e.resolved[key] = val
+	return val, nil
+}
+
+func (e *envExpander) expand(key, val string, stack []string) (string, error) {
+	var builder strings.Builder
+
+	for {
+		start := strings.Index(val, "${")
+		if start < 0 {
+			builder.WriteString(val)
+			return builder.String(), nil
+		}
+
+		if start > 0 && (val[start-1] == '$' || val[start-1] == '\\') {
+			builder.WriteString(val[:start-1] + "${")
+			val = val[start+2:]
+			continue
+		}
+
+		end := matchingBrace(val, start+2)
+		if end < 0 {
+			return "", fmt.Errorf("%s: unterminated ${ in value", key)
+		}
+
+		builder.WriteString(val[:start])
+
+		ref, err := e.reference(key, val[start+2:end], stack)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(ref)
+
+		val = val[end+1:]
+	}
+}
+
+// reference expands the inside of a single ${...} expression.
+func (e *envExpander) reference(key, expr string, stack []string) (string, error) {
+	name, operand, op := expr, "", ""
+	if idx := strings.Index(expr, ":"); idx >= 0 && idx+1 < len(expr) && (expr[idx+1] == '-' || expr[idx+1] == '?') {
+		name, op, operand = expr[:idx], expr[idx:idx+2], expr[idx+2:]
+	}
+
+	if name == "" {
+		return "", fmt.Errorf("%s: empty variable name in ${%s}", key, expr)
+	}
+
+	// a value referring to its own key (FOO=${FOO:-x}) has nothing to fall back on
+	val := ""
+	if _, exists := e.raw[name]; exists && name != key {
+		var err error
+		if val, err = e.resolve(name, stack); err != nil {
+			return "", err
+		}
+	}
+
+	if val != "" {
+		return val, nil
+	}
+
+	switch op {
+	case ":-":
+		return e.expand(key, operand, stack)
+	case ":?":
+		message := operand
+		if message == "" {
+			message = "is not set"
+		}
+		return "", fmt.Errorf("%s: %s %s", key, name, message)
+	}
+
+	return "", nil
+}
+
+// matchingBrace returns the index of the } closing the expression that starts
+// at from, allowing nested ${...} in defaults.
+func matchingBrace(val string, from int) int {
+	depth := 1
+	for i := from; i < len(val); i++ {
+		switch val[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
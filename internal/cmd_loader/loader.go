@@ -4,46 +4,58 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/reduan2660/swapenv/internal/filehandler"
 	"github.com/reduan2660/swapenv/internal/types"
 )
 
-func Load(env string, replace bool) error {
+type LoadOptions struct {
+	Env     string   // env to load from the configured patterns, "*" for all
+	Paths   []string // explicit files to load instead of the patterns
+	As      string   // env name for a single explicit file
+	Replace bool
+	Keep    bool   // keep source files after loading
+	Message string // recorded on the new version
+}
+
+func Load(opts LoadOptions) error {
 
 	projectName, localOwner, localDirectory, homeDirectory, _, err := GetBasicInfo(GetBasicInfoOptions{ReadOnly: false})
 	if err != nil {
 		return err
 	}
 
-	filename := "." + env + ".env"
-
-	files, err := filepath.Glob(filename)
+	cfg, err := filehandler.ReadProjectConfig(localDirectory)
 	if err != nil {
-		return fmt.Errorf("error getting files: %w", err)
+		return err
 	}
 
-	if len(files) == 0 {
-		fmt.Print("no environment to load")
-		return nil
+	patterns, err := CompilePatterns(cfg.LoadPatterns)
+	if err != nil {
+		return err
 	}
 
-	newVersion, err := filehandler.BumpVersion(projectName)
+	var sources map[string]string
+	if len(opts.Paths) > 0 {
+		sources, err = explicitSources(patterns, opts.Paths, opts.As)
+	} else {
+		sources, err = patternSources(localDirectory, patterns, opts.Env)
+	}
 	if err != nil {
-		return fmt.Errorf("error bumping version: %w", err)
+		return err
 	}
 
-	versionPath, err := filehandler.GetVersionFilePath(projectName, newVersion)
-	if err != nil {
-		return fmt.Errorf("error getting version path: %w", err)
+	if len(sources) == 0 {
+		fmt.Print("no environment to load")
+		return nil
 	}
 
 	envs := map[string][]types.EnvValue{}
+	files := make([]string, 0, len(sources))
 
-	for _, file := range files {
-
-		envName := strings.ToLower(strings.Split(file, ".")[1])
+	for envName, file := range sources {
 
 		fileContent, err := os.ReadFile(file)
 		if err != nil {
@@ -52,52 +64,210 @@ func Load(env string, replace bool) error {
 
 		envValues, err := ParseEnv(fileContent)
 		if err != nil {
-			return err
+			return fmt.Errorf("error parsing %s: %w", file, err)
 		}
 		envs[envName] = envValues
+		files = append(files, file)
+	}
+
+	slices.Sort(files)
+	storeOpts := StoreOptions{
+		Replace:   opts.Replace,
+		Operation: types.OpLoad,
+		Source:    files,
+		Message:   opts.Message,
+	}
+	if _, err := StoreEnvs(projectName, localOwner, localDirectory, homeDirectory, envs, storeOpts); err != nil {
+		return err
+	}
+
+	// explicitly named files are never deleted, only the ones the patterns found
+	if !opts.Keep && len(opts.Paths) == 0 {
+		if err := filehandler.DeleteEnvFiles(files); err != nil {
+			return err
+		}
+	}
+
+	fmt.Print("loaded environment:")
+	for envName := range envs {
+		fmt.Printf(" %s", envName)
+	}
+
+	return nil
+}
+
+// patternSources finds env files through the load patterns, keyed by env name.
+// Only files inside the project are read, and later deleted.
+func patternSources(localDirectory string, patterns []*EnvPattern, env string) (map[string]string, error) {
+	sources := make(map[string]string)
+
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern.Glob(env))
+		if err != nil {
+			return nil, fmt.Errorf("error getting files: %w", err)
+		}
+
+		for _, file := range files {
+			envName, ok := pattern.Match(file)
+			if !ok {
+				continue
+			}
+			if err := filehandler.InProject(localDirectory, file); err != nil {
+				return nil, err
+			}
+			envName = strings.ToLower(envName)
+
+			if existing, ok := sources[envName]; ok && existing != file {
+				return nil, fmt.Errorf("both %s and %s provide environment '%s'", existing, file, envName)
+			}
+			sources[envName] = file
+		}
 	}
 
-	if !replace {
+	return sources, nil
+}
 
-		prevVersion := newVersion - 1
-		if prevVersion > 0 {
-			prevPath, _ := filehandler.GetVersionFilePath(projectName, prevVersion)
+// explicitSources names explicit files by --as or, failing that, by the load patterns
+func explicitSources(patterns []*EnvPattern, paths []string, as string) (map[string]string, error) {
+	if as != "" && len(paths) > 1 {
+		return nil, fmt.Errorf("--as can only be used with a single file")
+	}
 
-			for envName := range envs {
-				existingEnvValues, err := filehandler.ReadProjectEnv(prevPath, envName)
-				if err == nil {
-					envs[envName] = MergeEnv(envs[envName], existingEnvValues, MergeEnvConfig{
-						ConflictPriority: "incoming",
-					})
-				}
+	sources := make(map[string]string)
+	for _, path := range paths {
+		envName := as
+		if envName == "" {
+			var ok bool
+			if envName, ok = MatchAny(patterns, path); !ok {
+				return nil, fmt.Errorf("can't tell the environment of %s, use --as", path)
 			}
+		}
+		envName = strings.ToLower(envName)
+
+		if existing, ok := sources[envName]; ok {
+			return nil, fmt.Errorf("both %s and %s provide environment '%s'", existing, path, envName)
+		}
+		sources[envName] = path
+	}
+
+	return sources, nil
+}
+
+type StoreOptions struct {
+	Replace   bool // store the loaded envs as given instead of merging them with their previous values
+	Snapshot  bool // envs are the whole version, nothing is merged or carried forward
+	Operation string
+	Source    []string
+	Message   string
+}
+
+// StoreEnvs writes envs as a new version of the project and prunes old versions.
+func StoreEnvs(projectName, localOwner, localDirectory, homeDirectory string, envs map[string][]types.EnvValue, opts StoreOptions) (int, error) {
+
+	for envName := range envs {
+		if slices.Contains(filehandler.ProjectHeaderFields, envName) {
+			return 0, fmt.Errorf("'%s' is reserved and can't be used as an environment name", envName)
+		}
+	}
+
+	// taken before other envs are carried forward
+	loaded := make([]string, 0, len(envs))
+	for envName := range envs {
+		loaded = append(loaded, envName)
+	}
 
+	// fast forward from the version in use, which isn't the latest after a
+	// rollback, and for pinned envs is the pinned version
+	project, err := filehandler.FindProjectByName(projectName)
+	if err != nil {
+		return 0, fmt.Errorf("error reading project map: %w", err)
+	}
+
+	if !opts.Snapshot && project != nil && project.CurrentVersion > 0 {
+		currentEnvs, err := readCurrentEnvs(projectName, project)
+		if err != nil {
+			return 0, err
+		}
+		if !opts.Replace {
+			if err := fastForward(projectName, project, currentEnvs, envs); err != nil {
+				return 0, err
+			}
 		}
+		carryForward(currentEnvs, envs)
 	}
 
 	newProject := MarshalProject(projectName, localOwner, localDirectory, envs)
+	newProject.Operation = opts.Operation
+	newProject.Source = opts.Source
+	newProject.Message = opts.Message
 
 	projectJson, err := newProject.MarshalJSON()
 	if err != nil {
-		return fmt.Errorf("error generating json: %w", err)
+		return 0, fmt.Errorf("error generating json: %w", err)
 	}
 
-	if err := filehandler.WriteProject(homeDirectory, versionPath, projectJson); err != nil {
-		return err
+	newVersion, err := filehandler.StoreVersion(projectName, projectJson)
+	if err != nil {
+		return 0, fmt.Errorf("error storing version: %w", err)
 	}
 
-	if err := filehandler.DeleteEnvFiles(files); err != nil {
-		return err
+	// loaded envs follow the new version again
+	if err := filehandler.UnpinEnvs(projectName, loaded); err != nil {
+		return 0, fmt.Errorf("error updating project map: %w", err)
 	}
 
-	if err := filehandler.PruneVersions(projectName); err != nil {
-		return fmt.Errorf("error pruning versions: %w", err)
+	// the version is stored by now, a failed prune only leaves old versions around
+	if _, err := filehandler.PruneVersions(projectName); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: error pruning versions: %v\n", err)
 	}
 
-	fmt.Print("loaded environment:")
-	for envName := range envs {
-		fmt.Printf(" %s", envName)
+	return newVersion, nil
+}
+
+// readCurrentEnvs reads the envs of the version in use, a missing version
+// file reads as no envs.
+func readCurrentEnvs(projectName string, project *types.ProjectDir) (map[string][]types.EnvValue, error) {
+	currentPath, err := filehandler.GetVersionFilePath(projectName, project.CurrentVersion)
+	if err != nil {
+		return nil, fmt.Errorf("error getting version path: %w", err)
+	}
+
+	currentEnvs, err := filehandler.ReadProjectEnvs(currentPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading v%d: %w", project.CurrentVersion, err)
+	}
+	return currentEnvs, nil
+}
+
+// fastForward merges each loaded env with the version it is read from.
+func fastForward(projectName string, project *types.ProjectDir, currentEnvs, envs map[string][]types.EnvValue) error {
+	for envName, incoming := range envs {
+		existingEnvValues, ok := currentEnvs[envName]
+		if baseVersion := filehandler.EnvVersion(project, envName); baseVersion != project.CurrentVersion {
+			basePath, err := filehandler.GetVersionFilePath(projectName, baseVersion)
+			if err != nil {
+				return fmt.Errorf("error getting version path: %w", err)
+			}
+			existingEnvValues, err = filehandler.ReadProjectEnv(basePath, envName)
+			ok = err == nil
+		}
+
+		if ok {
+			envs[envName] = MergeEnv(incoming, existingEnvValues, MergeEnvConfig{
+				ConflictPriority: "incoming",
+			})
+		}
 	}
 
 	return nil
 }
+
+// carryForward adds the envs of the current version that weren't loaded, so
+// loading some envs never drops the others.
+func carryForward(currentEnvs, envs map[string][]types.EnvValue) {
+	for envName, existingEnvValues := range currentEnvs {
+		if _, loaded := envs[envName]; !loaded {
+			envs[envName] = existingEnvValues // carried forward as is
+		}
+	}
+}
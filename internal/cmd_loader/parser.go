@@ -1,8 +1,7 @@
 package cmd_loader
 
 import (
-	"bufio"
-	"bytes"
+	"fmt"
 	"strings"
 	"time"
 
@@ -10,58 +9,187 @@ import (
 	"github.com/reduan2660/swapenv/internal/types"
 )
 
+// ParseEnv tokenizes dotenv content.
+//
+// Supported grammar:
+//   - optional `export ` prefix before the key
+//   - unquoted values, trimmed, with trailing ` # comments` removed
+//   - double-quoted values with escapes (\n, \r, \t, \", \\, \$), may span lines
+//   - single-quoted values taken literally, may span lines
+//
+// Blank lines before a key are recorded in Spacing, comments in Comments,
+// InlineComment and, after the last key, Trailing.
 func ParseEnv(content []byte) ([]types.EnvValue, error) {
 
 	envValues := make([]types.EnvValue, 0)
 
-	scanner := bufio.NewScanner(bytes.NewReader(content))
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	lines := strings.Split(text, "\n")
 	order := 1
 	spacing := 0
+	var comments []string
 
-	for scanner.Scan() {
+	for i := 0; i < len(lines); i++ {
 
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			spacing++
+		line := strings.TrimLeft(lines[i], " \t")
+		if strings.TrimSpace(line) == "" {
+			if len(comments) == 0 {
+				spacing++
+			} else {
+				comments = append(comments, "")
+			}
+			continue
 		}
 
-		if strings.HasPrefix(line, "#") { // TODO: consider digits, and ?
+		if strings.HasPrefix(line, "#") {
+			comments = append(comments, strings.TrimRight(line, " \t"))
 			continue
 		}
 
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
+		line = stripExport(line)
+
+		key, rest, found := strings.Cut(line, "=")
+		if !found {
 			continue
 		}
 
-		key := strings.TrimSpace(parts[0])
-		val := strings.TrimSpace(parts[1])
-
+		key = strings.TrimSpace(key)
 		if len(key) == 0 {
 			continue
 		}
 
-		val = strings.Trim(val, `"'`)
+		val, comment, literal, consumed, err := parseValue(rest, lines[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", i+1, key, err)
+		}
+		i += consumed
 
 		envValues = append(envValues, types.EnvValue{
-			Key:     key,
-			Val:     val,
-			Order:   order,
-			Spacing: spacing,
+			Key:           key,
+			Val:           val,
+			Order:         order,
+			Spacing:       spacing,
+			Comments:      comments,
+			InlineComment: comment,
+			Literal:       literal,
 		})
 
 		order++
 		spacing = 0
-
+		comments = nil
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	for len(comments) > 0 && comments[len(comments)-1] == "" {
+		comments = comments[:len(comments)-1]
+	}
+	if len(comments) > 0 && len(envValues) > 0 {
+		envValues[len(envValues)-1].Trailing = comments
 	}
 
 	return envValues, nil
 }
 
+func stripExport(line string) string {
+	rest, found := strings.CutPrefix(line, "export")
+	if !found || len(rest) == 0 || (rest[0] != ' ' && rest[0] != '\t') {
+		return line
+	}
+	return strings.TrimLeft(rest, " \t")
+}
+
+// parseValue reads the value that starts at rest. Quoted values may continue
+// into the following lines; consumed reports how many of them were used.
+// literal is set for single quoted values, which are never interpolated.
+func parseValue(rest string, following []string) (val, comment string, literal bool, consumed int, err error) {
+	trimmed := strings.TrimLeft(rest, " \t")
+	if trimmed == "" {
+		return "", "", false, 0, nil
+	}
+	// KEY= #comment has an empty value, KEY=#fff does not
+	if trimmed[0] == '#' && len(trimmed) < len(rest) {
+		return "", trimmed, false, 0, nil
+	}
+	rest = trimmed
+
+	quote := rest[0]
+	if quote != '"' && quote != '\'' {
+		val, comment = splitInlineComment(rest)
+		return val, comment, false, 0, nil
+	}
+
+	body := rest[1:]
+	for {
+		if val, end, ok := scanQuoted(body, quote); ok {
+			remainder := strings.TrimSpace(body[end:])
+			if remainder != "" && !strings.HasPrefix(remainder, "#") {
+				return "", "", false, 0, fmt.Errorf("unexpected %q after the closing quote", remainder)
+			}
+			return val, remainder, quote == '\'', consumed, nil
+		}
+		if consumed == len(following) {
+			return "", "", false, 0, fmt.Errorf("unterminated quoted value")
+		}
+		body += "\n" + following[consumed]
+		consumed++
+	}
+}
+
+// scanQuoted returns the content up to the closing quote and the index just
+// past it, or false if the quote is not closed within body.
+func scanQuoted(body string, quote byte) (string, int, bool) {
+	if quote == '\'' {
+		idx := strings.IndexByte(body, '\'')
+		if idx < 0 {
+			return "", 0, false
+		}
+		return body[:idx], idx + 1, true
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case c == '"':
+			return builder.String(), i + 1, true
+		case c == '\\' && i+1 < len(body):
+			i++
+			switch body[i] {
+			case 'n':
+				builder.WriteByte('\n')
+			case 'r':
+				builder.WriteByte('\r')
+			case 't':
+				builder.WriteByte('\t')
+			case '$':
+				// \${ stays escaped for ExpandEnv, a lone \$ is just $
+				if i+1 < len(body) && body[i+1] == '{' {
+					builder.WriteByte('\\')
+				}
+				builder.WriteByte('$')
+			case '"', '\\':
+				builder.WriteByte(body[i])
+			default:
+				builder.WriteByte('\\')
+				builder.WriteByte(body[i])
+			}
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return "", 0, false
+}
+
+// splitInlineComment separates a `#` comment from an unquoted value. The `#`
+// only starts a comment when preceded by whitespace, so values like `#fff` survive.
+func splitInlineComment(val string) (string, string) {
+	for i := 1; i < len(val); i++ {
+		if val[i] == '#' && (val[i-1] == ' ' || val[i-1] == '\t') {
+			return strings.TrimSpace(val[:i]), strings.TrimSpace(val[i:])
+		}
+	}
+	return strings.TrimSpace(val), ""
+}
+
 type MergeEnvConfig struct {
 	Replace          bool   // if true, just return incoming (ignore current)
 	ConflictPriority string // "incoming" or "current" - which value wins for matching keys
@@ -75,6 +203,7 @@ type MergeEnvConfig struct {
 //   - "current": use current's value
 //
 // If Replace=true, just return incoming (ignore current entirely).
+// Comments travel with the winning value; end-of-file comments stay at the end.
 func MergeEnv(incoming, current []types.EnvValue, config MergeEnvConfig) []types.EnvValue {
 
 	if config.Replace {
@@ -112,9 +241,33 @@ func MergeEnv(incoming, current []types.EnvValue, config MergeEnvConfig) []types
 		}
 	}
 
+	// end-of-file comments follow the same priority as conflicting values
+	trailing := collectTrailing(incoming)
+	if config.ConflictPriority != "incoming" || len(trailing) == 0 {
+		if currentTrailing := collectTrailing(current); len(currentTrailing) > 0 {
+			trailing = currentTrailing
+		}
+	}
+
+	for i := range merged {
+		merged[i].Trailing = nil
+	}
+	if len(merged) > 0 {
+		merged[len(merged)-1].Trailing = trailing
+	}
+
 	return merged
 }
 
+func collectTrailing(envValues []types.EnvValue) []string {
+	for _, ev := range envValues {
+		if len(ev.Trailing) > 0 {
+			return ev.Trailing
+		}
+	}
+	return nil
+}
+
 func MarshalProject(projectName, owner, localDirectory string, envs map[string][]types.EnvValue) types.Project {
 
 	now := time.Now().UTC().Unix()
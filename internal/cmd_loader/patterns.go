@@ -0,0 +1,98 @@
+package cmd_loader
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/filehandler"
+)
+
+const envPlaceholder = "{env}"
+
+// EnvPattern is a load pattern such as `.{env}.env` or `config/.env.{env}`,
+// where {env} captures the env name and * / ? behave like in globs.
+type EnvPattern struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func CompilePattern(pattern string) (*EnvPattern, error) {
+	if err := filehandler.CheckRelativePath(pattern); err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	pattern = filepath.ToSlash(pattern)
+	if strings.Count(pattern, envPlaceholder) != 1 {
+		return nil, fmt.Errorf("pattern '%s' must contain %s exactly once", pattern, envPlaceholder)
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for rest := pattern; rest != ""; {
+		switch {
+		case strings.HasPrefix(rest, envPlaceholder):
+			expr.WriteString("(?P<env>.+)")
+			rest = rest[len(envPlaceholder):]
+			continue
+		case rest[0] == '*':
+			expr.WriteString("[^/]*")
+		case rest[0] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(rest[:1]))
+		}
+		rest = rest[1:]
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+	}
+
+	return &EnvPattern{pattern: pattern, re: re}, nil
+}
+
+func CompilePatterns(patterns []string) ([]*EnvPattern, error) {
+	compiled := make([]*EnvPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		p, err := CompilePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, p)
+	}
+	return compiled, nil
+}
+
+// Glob returns the glob for env, "*" matching any env
+func (p *EnvPattern) Glob(env string) string {
+	return filepath.FromSlash(strings.Replace(p.pattern, envPlaceholder, env, 1))
+}
+
+// Render returns the file path for env
+func (p *EnvPattern) Render(env string) string {
+	return p.Glob(env)
+}
+
+// Match returns the env name captured from path
+func (p *EnvPattern) Match(path string) (string, bool) {
+	path = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "./")
+	match := p.re.FindStringSubmatch(path)
+	if match == nil {
+		return "", false
+	}
+	return match[p.re.SubexpIndex("env")], true
+}
+
+// MatchAny returns the env name from the first pattern matching path
+func MatchAny(patterns []*EnvPattern, path string) (string, bool) {
+	for _, p := range patterns {
+		if env, ok := p.Match(path); ok {
+			return env, true
+		}
+	}
+	return "", false
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/reduan2660/swapenv/internal/api"
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
 	"github.com/reduan2660/swapenv/internal/cmd_login"
 	"github.com/reduan2660/swapenv/internal/cmd_logout"
 	"github.com/reduan2660/swapenv/internal/crypto"
@@ -22,7 +23,7 @@ type wsMessage struct {
 	Message string   `json:"message,omitempty"`
 }
 
-func Receive(serverURL string) error {
+func Receive(serverURL, message string) error {
 	if !api.IsLoggedIn() {
 		fmt.Println("Not logged in. Starting login flow...")
 		if err := cmd_login.Login(serverURL); err != nil {
@@ -47,6 +48,7 @@ func Receive(serverURL string) error {
 		return err
 	}
 
+	var streamCode string
 	switch msg.Type {
 	case "error":
 		return fmt.Errorf("server error: %s", msg.Message)
@@ -72,9 +74,11 @@ func Receive(serverURL string) error {
 		if msg.Type == "error" {
 			return fmt.Errorf("server error: %s", msg.Message)
 		}
+		streamCode = choice
 
 	case "connected":
 		fmt.Printf("Connected to stream: %s\n", msg.Code)
+		streamCode = msg.Code
 	}
 
 	privKey, err := crypto.GenerateKeyPair()
@@ -118,7 +122,7 @@ func Receive(serverURL string) error {
 		return fmt.Errorf("invalid env data: %w", err)
 	}
 
-	version, err := saveReceived(projectName, envMap)
+	version, err := saveReceived(projectName, envMap, receivedFrom{Stream: streamCode, Message: message})
 	if err != nil {
 		return fmt.Errorf("failed to save: %w", err)
 	}
@@ -130,67 +134,75 @@ func Receive(serverURL string) error {
 	return nil
 }
 
-func saveReceived(projectName string, envMap map[string][]types.EnvValue) (int, error) {
+// receivedFrom is recorded on the version a receive creates
+type receivedFrom struct {
+	Stream  string
+	Message string
+}
+
+func saveReceived(projectName string, envMap map[string][]types.EnvValue, from receivedFrom) (int, error) {
 	project, err := filehandler.FindProjectByName(projectName)
 	if err != nil {
 		return 0, err
 	}
 
-	var version int
-
 	if project == nil {
+		// versions start once the received one is written
 		newProject := types.ProjectDir{
-			ProjectName:    projectName,
-			LocalPath:      "",
-			CurrentEnv:     "",
-			CurrentVersion: 1,
-			LatestVersion:  1,
-			VersionNames:   make(map[string]string),
+			ProjectName:  projectName,
+			LocalPath:    "",
+			CurrentEnv:   "",
+			VersionNames: make(map[string]string),
 		}
 		if err := filehandler.UpsertProjectDir(newProject); err != nil {
 			return 0, err
 		}
-		version = 1
 		fmt.Printf("New project: %s (use 'swapenv map %s' to assign directory)\n", projectName, projectName)
-	} else {
-		version, err = filehandler.BumpVersion(projectName)
-		if err != nil {
-			return 0, err
-		}
 	}
 
-	versionPath, err := filehandler.GetVersionFilePath(projectName, version)
-	if err != nil {
-		return 0, err
+	localDir := ""
+	if project != nil {
+		localDir = project.LocalPath
 	}
 
-	homeDir, err := filehandler.GetHomeDirectory(projectName)
+	localOwner, err := cmd_loader.GetLocalOwner()
 	if err != nil {
 		return 0, err
 	}
 
-	localDir := ""
-	if project != nil {
-		localDir = project.LocalPath
-	}
-
 	now := time.Now().UTC().Unix()
 	projectData := types.Project{
 		Id:             uuid.New().String(),
 		Name:           projectName,
-		Owner:          "received",
+		Owner:          localOwner,
 		LocalDirectory: localDir,
 		CreatedAt:      now,
 		ModifiedAt:     now,
+		Operation:      types.OpReceive,
+		Message:        from.Message,
 		Envs:           envMap,
 	}
+	// the server doesn't tell who shared, the stream code is all there is
+	if from.Stream != "" {
+		projectData.Source = []string{"stream " + from.Stream}
+	}
 
 	projectJSON, err := projectData.MarshalJSON()
 	if err != nil {
 		return 0, err
 	}
 
-	if err := filehandler.WriteProject(homeDir, versionPath, projectJSON); err != nil {
+	version, err := filehandler.StoreVersion(projectName, projectJSON)
+	if err != nil {
+		return 0, err
+	}
+
+	// received envs follow the new version again, like loaded ones
+	received := make([]string, 0, len(envMap))
+	for envName := range envMap {
+		received = append(received, envName)
+	}
+	if err := filehandler.UnpinEnvs(projectName, received); err != nil {
 		return 0, err
 	}
 
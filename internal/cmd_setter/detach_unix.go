@@ -0,0 +1,13 @@
+//go:build !windows
+
+package cmd_setter
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach lets cmd outlive swapenv and the terminal it was started from.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
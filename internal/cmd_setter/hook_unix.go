@@ -0,0 +1,9 @@
+//go:build !windows
+
+package cmd_setter
+
+import "os/exec"
+
+func shellCommand(command string) *exec.Cmd {
+	return exec.Command("sh", "-c", command)
+}
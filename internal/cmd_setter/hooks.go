@@ -0,0 +1,65 @@
+package cmd_setter
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/reduan2660/swapenv/internal/filehandler"
+)
+
+// SwapInfo describes a swap to the pre_swap and post_swap hooks.
+type SwapInfo struct {
+	Project string
+	FromEnv string
+	ToEnv   string
+	Version int
+}
+
+func (s SwapInfo) environ() []string {
+	return append(os.Environ(),
+		"SWAPENV_PROJECT="+s.Project,
+		"SWAPENV_FROM_ENV="+s.FromEnv,
+		"SWAPENV_TO_ENV="+s.ToEnv,
+		"SWAPENV_VERSION="+strconv.Itoa(s.Version),
+	)
+}
+
+// runHooks runs each command through the shell in dir, streaming its output.
+// The first failing command stops the rest.
+func runHooks(kind, dir string, commands []string, info SwapInfo) error {
+	for _, command := range commands {
+		fmt.Printf("running %s: %s\n", kind, command)
+
+		hook := shellCommand(command)
+		hook.Dir = dir
+		hook.Env = info.environ()
+		hook.Stdin = os.Stdin
+		hook.Stdout = os.Stdout
+		hook.Stderr = os.Stderr
+
+		if err := hook.Run(); err != nil {
+			return fmt.Errorf("%s hook '%s' failed: %w", kind, command, err)
+		}
+	}
+	return nil
+}
+
+// checkHooksApproved refuses hooks from the project's .swapenv.yaml until
+// they are approved with `swapenv hooks approve`, it comes with the repo and
+// could run anything.
+func checkHooksApproved(localDirectory string) error {
+	digest, err := filehandler.ProjectHooksDigest(localDirectory)
+	if err != nil || digest == "" {
+		return err
+	}
+
+	approved, err := filehandler.IsHooksApproved(localDirectory, digest)
+	if err != nil {
+		return err
+	}
+	if !approved {
+		return fmt.Errorf("the hooks in %s are new or changed, review them with `swapenv hooks` and run `swapenv hooks approve`, or pass --no-hooks", filehandler.ProjectConfigFile)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package cmd_setter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+	"github.com/spf13/viper"
+)
+
+// NoWatcherEnv turns off the background process that reverts a temporary
+// swap on time, leaving it to the next swapenv command.
+const NoWatcherEnv = "SWAPENV_NO_WATCHER"
+
+// nextLease returns the lease for a swap to env lasting d, or nil for a
+// permanent swap. Swapping again during a temporary swap still goes back to
+// the env that was active before it.
+func nextLease(projectName, env string, d time.Duration) (*types.EnvLease, error) {
+	if d <= 0 {
+		return nil, nil
+	}
+
+	project, err := filehandler.FindProjectByName(projectName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading project map: %w", err)
+	}
+
+	lease := &types.EnvLease{Env: env, ExpiresAt: time.Now().Add(d).Unix()}
+	switch {
+	case project == nil:
+	case project.Lease != nil:
+		lease.PreviousEnv = project.Lease.PreviousEnv
+		lease.PreviousVersion = project.Lease.PreviousVersion
+	case project.CurrentEnv != "":
+		lease.PreviousEnv = project.CurrentEnv
+		lease.PreviousVersion = filehandler.EnvVersion(project, project.CurrentEnv)
+	}
+
+	return lease, nil
+}
+
+// LeaseTarget names the env a temporary swap reverts to.
+func LeaseTarget(lease *types.EnvLease) string {
+	if lease.PreviousEnv == "" {
+		return "no env"
+	}
+	return lease.PreviousEnv
+}
+
+// startWatcher starts a detached `swapenv expire` that reverts the lease when
+// it runs out.
+func startWatcher(localDirectory, projectName string, expiresAt int64) error {
+	if os.Getenv(NoWatcherEnv) != "" {
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("error starting expiry watcher: %w", err)
+	}
+
+	args := []string{"expire", "--project", projectName, "--at", strconv.FormatInt(expiresAt, 10)}
+	if cfgFile := viper.ConfigFileUsed(); cfgFile != "" {
+		args = append(args, "--config", cfgFile)
+	}
+
+	watcher := exec.Command(exe, args...)
+	watcher.Dir = localDirectory
+	detach(watcher)
+
+	if err := watcher.Start(); err != nil {
+		return fmt.Errorf("error starting expiry watcher: %w", err)
+	}
+	return watcher.Process.Release()
+}
+
+// ExpireLease waits until the lease of projectName expiring at expiresAt runs
+// out and reverts it, unless it was replaced or cleared in the meantime.
+func ExpireLease(projectName string, expiresAt int64) error {
+	deadline := time.Unix(expiresAt, 0)
+
+	// short naps, so time spent suspended still counts
+	for now := time.Now(); now.Before(deadline); now = time.Now() {
+		time.Sleep(min(deadline.Sub(now), time.Minute))
+	}
+
+	return revertDue(projectName, time.Now(), expiresAt)
+}
+
+// RevertExpired reverts every temporary swap that ran out by now.
+func RevertExpired(now time.Time) error {
+	expired, err := filehandler.ExpiredLeases(now)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, project := range expired {
+		if err := revertDue(project.ProjectName, now, 0); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", project.ProjectName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// revertDue reverts the project's lease if it is still due under the lease
+// lock, it may have been reverted, replaced or cleared since it was read. A
+// non-zero expiresAt only reverts the lease expiring then.
+func revertDue(projectName string, now time.Time, expiresAt int64) error {
+	return filehandler.LockLease(projectName, func(project *types.ProjectDir) error {
+		lease := project.Lease
+		if lease == nil || now.Before(filehandler.LeaseDeadline(lease)) {
+			return nil
+		}
+		if expiresAt != 0 && lease.ExpiresAt != expiresAt {
+			return nil
+		}
+		return revertLease(project)
+	})
+}
+
+// revertLease swaps a project back to the env it had before the temporary
+// swap. The temporary env's keys are taken out of the targets first, so none
+// of them linger after the revert, keys that aren't from either env stay.
+func revertLease(project *types.ProjectDir) error {
+	lease := project.Lease
+	fmt.Printf("%s: %s expired, reverting to %s\n", project.ProjectName, lease.Env, LeaseTarget(lease))
+
+	// nobody is there to confirm or save anything, the previous env was active
+	// before anyway and edits to the temporary env go with it
+	opts := SetOptions{Force: true, Confirmed: lease.PreviousEnv}
+	if lease.PreviousEnv != "" && lease.PreviousVersion != filehandler.EnvVersion(project, lease.PreviousEnv) {
+		if _, err := filehandler.ResolveVersion(project.ProjectName, strconv.Itoa(lease.PreviousVersion)); err != nil {
+			return fmt.Errorf("can't restore %s v%d, swap to an env to end the temporary swap: %w", lease.PreviousEnv, lease.PreviousVersion, err)
+		}
+		opts.Version = strconv.Itoa(lease.PreviousVersion)
+	}
+
+	if err := stripEnv(project, lease.PreviousEnv == ""); err != nil {
+		return err
+	}
+
+	if lease.PreviousEnv == "" {
+		if err := filehandler.UpdateCurrentEnv(project.ProjectName, ""); err != nil {
+			return fmt.Errorf("error updating current env: %w", err)
+		}
+		return filehandler.SetLease(project.ProjectName, nil)
+	}
+
+	if err := checkHooksApproved(project.LocalPath); err != nil {
+		fmt.Printf("skipping hooks: %v\n", err)
+		opts.NoHooks = true
+	}
+	return swapProject(project.ProjectName, project.LocalPath, lease.PreviousEnv, opts)
+}
+
+// stripEnv takes the keys of the active env out of the project's targets.
+// Managed blocks are left for the next swap to replace, unless removeBlock is set.
+func stripEnv(project *types.ProjectDir, removeBlock bool) error {
+	cfg, err := filehandler.ReadProjectConfig(project.LocalPath)
+	if err != nil {
+		return err
+	}
+
+	values, err := ResolveEnv(project.ProjectName, "", project.CurrentEnv, ResolveOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, target := range cfg.Targets {
+		path := TargetPath(project.LocalPath, target)
+		if err := filehandler.InProject(project.LocalPath, path); err != nil {
+			return fmt.Errorf("can't write target: %w", err)
+		}
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		var content []byte
+		switch {
+		case !filehandler.HasManagedBlock(data):
+			content, err = removeKeys(data, TargetValues(target, values))
+		case removeBlock:
+			content, err = filehandler.RemoveManagedBlock(data)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error updating %s: %w", target.Path, err)
+		}
+
+		if err := applyTarget(project.ProjectName, targetPlan{Target: target, Path: path, Content: content}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func removeKeys(data []byte, values []types.EnvValue) ([]byte, error) {
+	current, err := cmd_loader.ParseEnv(data)
+	if err != nil {
+		return nil, err
+	}
+
+	current = slices.DeleteFunc(current, func(ev types.EnvValue) bool {
+		return slices.ContainsFunc(values, func(v types.EnvValue) bool { return v.Key == ev.Key })
+	})
+
+	return []byte(filehandler.RenderEnv(current, true)), nil
+}
@@ -0,0 +1,135 @@
+package cmd_setter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/cmd_version"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+// targetPlan is what a swap will write to one target.
+type targetPlan struct {
+	Target  types.EnvTarget
+	Path    string
+	Content []byte
+	Changes []cmd_loader.EnvChange // keys added, changed and removed
+	Kept    []string               // keys the incoming env doesn't have that stay in the file
+}
+
+// planTargets plans every target of the project.
+func planTargets(localDirectory string, cfg types.ProjectConfig, swap SwapInfo, envValues []types.EnvValue, opts SetOptions) ([]targetPlan, error) {
+	plans := make([]targetPlan, 0, len(cfg.Targets))
+	for _, target := range cfg.Targets {
+		plan, err := planTarget(localDirectory, target, swap, envValues, cfg.ManagedBlock, opts)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// planTarget renders the incoming env into a target without writing it.
+func planTarget(localDirectory string, target types.EnvTarget, swap SwapInfo, envValues []types.EnvValue, managedBlock bool, opts SetOptions) (targetPlan, error) {
+	plan := targetPlan{Target: target, Path: TargetPath(localDirectory, target)}
+	incoming := TargetValues(target, envValues)
+
+	// a symlinked directory could still lead outside the project
+	if err := filehandler.InProject(localDirectory, plan.Path); err != nil {
+		return plan, fmt.Errorf("can't write target: %w", err)
+	}
+
+	curEnvFile, err := os.ReadFile(plan.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return plan, err
+	}
+
+	if opts.Managed || managedBlock || filehandler.HasManagedBlock(curEnvFile) {
+		plan.Content, err = writeManagedBlock(swap, curEnvFile, incoming, opts)
+	} else {
+		plan.Content, err = mergeEnvFile(curEnvFile, incoming, opts)
+	}
+	if err != nil {
+		return plan, fmt.Errorf("error updating %s: %w", filepath.Base(plan.Path), err)
+	}
+
+	before, err := parseManaged(curEnvFile)
+	if err != nil {
+		return plan, fmt.Errorf("error parsing %s: %w", target.Path, err)
+	}
+	after, err := parseManaged(plan.Content)
+	if err != nil {
+		return plan, fmt.Errorf("error parsing %s: %w", target.Path, err)
+	}
+
+	plan.Changes = cmd_loader.DiffEnv(before, after)
+
+	incomingKeys := make([]string, 0, len(incoming))
+	for _, ev := range incoming {
+		incomingKeys = append(incomingKeys, ev.Key)
+	}
+	for _, ev := range after {
+		if !slices.Contains(incomingKeys, ev.Key) {
+			plan.Kept = append(plan.Kept, ev.Key)
+		}
+	}
+
+	return plan, nil
+}
+
+// applyTarget writes a planned target and remembers what was written, for
+// drift detection.
+func applyTarget(projectName string, plan targetPlan) error {
+	if dir := filepath.Dir(plan.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(plan.Path, plan.Content, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", filepath.Base(plan.Path), err)
+	}
+
+	return recordEnvFile(projectName, plan.Path, plan.Content, plan.Kept)
+}
+
+func printPlan(swap SwapInfo, plans []targetPlan, drifted []string, opts SetOptions) {
+	from := swap.FromEnv
+	if from == "" {
+		from = "none"
+	}
+	fmt.Printf("dry run: %s → %s (v%d), nothing written\n", from, swap.ToEnv, swap.Version)
+
+	for _, plan := range plans {
+		fmt.Printf("\n%s\n", plan.Target.Path)
+
+		changes := plan.Changes
+		if !opts.Reveal {
+			changes = cmd_loader.MaskChanges(changes)
+		}
+		if len(changes) == 0 {
+			fmt.Println("  no changes")
+		}
+		cmd_version.PrintChanges(changes)
+
+		for _, key := range plan.Kept {
+			fmt.Printf("  = %s (kept from the current file, --replace drops it)\n", key)
+		}
+
+		if slices.Contains(drifted, plan.Target.Path) {
+			fmt.Printf("  edited since the last swap, swapping needs --save or --force\n")
+		}
+	}
+}
+
+func parseManaged(data []byte) ([]types.EnvValue, error) {
+	managed, err := filehandler.ManagedContent(data)
+	if err != nil {
+		return nil, err
+	}
+	return cmd_loader.ParseEnv(managed)
+}
@@ -0,0 +1,124 @@
+package cmd_setter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+type ResolveOptions struct {
+	SkipCommon bool   // don't merge the envs it inherits from (common by default)
+	Version    string // version to read from (default: current)
+	Raw        bool   // don't expand ${VAR} references
+}
+
+// Resolved is an env merged with the envs it inherits from.
+type Resolved struct {
+	Chain     []string            // the env, then its ancestors nearest first
+	Values    []types.EnvValue    // merged values, the env's own order first
+	Origin    map[string]string   // env each key's value comes from
+	Overrides map[string][]string // ancestors whose value for a key was overridden
+}
+
+// ResolveEnv reads env and the envs it inherits from, merges them and expands
+// references - everything Set does before touching .env.
+func ResolveEnv(projectName, projectPath, env string, opts ResolveOptions) ([]types.EnvValue, error) {
+	resolved, err := Resolve(projectName, projectPath, env, opts)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.Values, nil
+}
+
+// Resolve walks the inheritance chain of env (see filehandler.EnvChain). Each
+// env is read from the requested version, or without one from its own version
+// (see filehandler.EnvVersion), in which case projectPath is not used. A
+// missing common env is skipped, any other missing ancestor is an error.
+func Resolve(projectName, projectPath, env string, opts ResolveOptions) (*Resolved, error) {
+	project, err := filehandler.FindProjectByName(projectName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading project map: %w", err)
+	}
+
+	localPath := ""
+	if project != nil {
+		localPath = project.LocalPath
+	}
+	cfg, err := filehandler.ReadProjectConfig(localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []string{env}
+	if !opts.SkipCommon {
+		if chain, err = filehandler.EnvChain(cfg, env); err != nil {
+			return nil, err
+		}
+	}
+
+	versionPath := ""
+	if opts.Version != "" {
+		version, err := filehandler.ResolveVersion(projectName, opts.Version)
+		if err != nil {
+			return nil, err
+		}
+		if versionPath, err = filehandler.GetVersionFilePath(projectName, version); err != nil {
+			return nil, err
+		}
+	}
+
+	resolved := &Resolved{
+		Origin:    make(map[string]string),
+		Overrides: make(map[string][]string),
+	}
+
+	for i, layer := range chain {
+		layerPath := versionPath
+		if layerPath == "" {
+			if layerPath, err = filehandler.GetEnvVersionFilePath(projectName, layer); err != nil {
+				return nil, err
+			}
+		}
+
+		values, err := filehandler.ReadProjectEnv(layerPath, layer)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			if layer == filehandler.BaseEnv {
+				continue
+			}
+			return nil, fmt.Errorf("environment '%s' extended by '%s' not found", layer, chain[i-1])
+		}
+		resolved.Chain = append(resolved.Chain, layer)
+
+		sort.Slice(values, func(i, j int) bool {
+			return values[i].Order < values[j].Order
+		})
+
+		for _, ev := range values {
+			if _, ok := resolved.Origin[ev.Key]; ok {
+				resolved.Overrides[ev.Key] = append(resolved.Overrides[ev.Key], layer)
+			} else {
+				resolved.Origin[ev.Key] = layer
+			}
+		}
+
+		// the nearer env's order first, its values win for conflicts
+		resolved.Values = cmd_loader.MergeEnv(values, resolved.Values, cmd_loader.MergeEnvConfig{
+			ConflictPriority: "current",
+		})
+	}
+
+	if !opts.Raw {
+		resolved.Values, err = cmd_loader.ExpandEnv(resolved.Values)
+		if err != nil {
+			return nil, fmt.Errorf("error expanding %s: %w", env, err)
+		}
+	}
+
+	return resolved, nil
+}
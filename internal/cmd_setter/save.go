@@ -0,0 +1,268 @@
+package cmd_setter
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+type SaveOptions struct {
+	Message string
+}
+
+// Save stores the edits made to the targets since the last swap as a new
+// version of the active env. Only keys added, changed or removed in a target
+// are applied, so keys from common stay out and unchanged values keep their
+// stored form, ${VAR} references included, as long as it still expands to them.
+func Save(opts SaveOptions) error {
+	projectName, localOwner, localDirectory, homeDirectory, _, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		fmt.Println("no project under current directory, use swapenv load to initiate.")
+		return nil
+	}
+
+	project, err := filehandler.FindProjectByName(projectName)
+	if err != nil {
+		return fmt.Errorf("error reading project map: %w", err)
+	}
+	if project == nil || project.CurrentEnv == "" {
+		return fmt.Errorf("no active environment to save into, use swapenv to <env> first")
+	}
+	env := project.CurrentEnv
+
+	cfg, err := filehandler.ReadProjectConfig(localDirectory)
+	if err != nil {
+		return err
+	}
+
+	envs, err := filehandler.ReadCurrentEnvs(projectName)
+	if err != nil {
+		return err
+	}
+
+	resolved, err := ResolveEnv(projectName, "", env, ResolveOptions{})
+	if err != nil {
+		return err
+	}
+
+	saved := envs[env]
+	written := make(map[string][]byte, len(cfg.Targets))
+	sources := make([]string, 0, len(cfg.Targets))
+	expected := make(map[string]string) // working values by stored key
+
+	for _, target := range cfg.Targets {
+		path := TargetPath(localDirectory, target)
+		working, data, err := ReadTarget(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		written[path] = data
+
+		// keys the last swap kept from another env aren't edits to this one
+		working = EditedValues(project, target, path, resolved, working)
+		for _, ev := range working {
+			expected[StoredKey(target, ev.Key)] = ev.Val
+		}
+
+		changes := cmd_loader.DiffEnv(TargetValues(target, resolved), working)
+		if len(changes) == 0 {
+			continue
+		}
+		saved = applyChanges(saved, target, changes, working)
+		sources = append(sources, target.Path)
+	}
+
+	if saved, err = keepExpansions(projectName, env, saved, expected); err != nil {
+		return err
+	}
+
+	changes := cmd_loader.DiffEnv(envs[env], saved)
+	if len(changes) == 0 {
+		fmt.Printf("nothing to save, targets match %s\n", env)
+		return recordTargets(project, written)
+	}
+
+	envs[env] = saved
+
+	// a full snapshot, fast forwarding would bring back removed keys
+	newVersion, err := cmd_loader.StoreEnvs(projectName, localOwner, localDirectory, homeDirectory, envs, cmd_loader.StoreOptions{
+		Snapshot:  true,
+		Operation: types.OpEdit,
+		Source:    sources,
+		Message:   opts.Message,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := recordTargets(project, written); err != nil {
+		return err
+	}
+
+	fmt.Printf("saved %s into %s as v%d (%s)\n", joinSources(sources), env, newVersion, summarizeChanges(changes))
+	return nil
+}
+
+// ReadTarget parses a target file, only its managed block if it has one.
+func ReadTarget(path string) ([]types.EnvValue, []byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	managed, err := filehandler.ManagedContent(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	values, err := cmd_loader.ParseEnv(managed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+
+	return values, data, nil
+}
+
+// applyChanges applies the changes found in a target to the stored env,
+// mapping target keys back to stored keys. Removing a key the env doesn't
+// define, e.g. one from common, does nothing.
+func applyChanges(stored []types.EnvValue, target types.EnvTarget, changes []cmd_loader.EnvChange, working []types.EnvValue) []types.EnvValue {
+	workingByKey := make(map[string]types.EnvValue, len(working))
+	for _, ev := range working {
+		workingByKey[ev.Key] = ev
+	}
+
+	removed := make(map[string]bool)
+	updated := make(map[string]types.EnvValue)
+	var order []string
+	for _, change := range changes {
+		key := StoredKey(target, change.Key)
+		if change.Kind == cmd_loader.KeyRemoved {
+			removed[key] = true
+			continue
+		}
+		ev := workingByKey[change.Key]
+		ev.Key = key
+		updated[key] = ev
+		order = append(order, key)
+	}
+
+	result := make([]types.EnvValue, 0, len(stored)+len(updated))
+	for _, ev := range stored {
+		if removed[ev.Key] {
+			continue
+		}
+		if edit, ok := updated[ev.Key]; ok {
+			ev.Val = edit.Val
+			ev.Literal = edit.Literal
+			delete(updated, ev.Key)
+		}
+		result = append(result, ev)
+	}
+
+	// new keys, and overrides of common keys, go last
+	for _, key := range order {
+		if ev, ok := updated[key]; ok {
+			result = append(result, ev)
+		}
+	}
+
+	return result
+}
+
+// keepExpansions makes sure the saved env reproduces the working values: a
+// stored ${VAR} form is only kept while it still expands to the value in the
+// targets, e.g. not once VAR was removed or changed.
+func keepExpansions(projectName, env string, saved []types.EnvValue, expected map[string]string) ([]types.EnvValue, error) {
+	resolved, err := Resolve(projectName, "", env, ResolveOptions{Raw: true})
+	if err != nil {
+		return nil, err
+	}
+
+	inherited := make([]types.EnvValue, 0, len(resolved.Values))
+	for _, ev := range resolved.Values {
+		if resolved.Origin[ev.Key] != env {
+			inherited = append(inherited, ev)
+		}
+	}
+
+	saved = slices.Clone(saved)
+
+	// each pass fixes at least one key, fixing a key can change what others expand to
+	for range len(saved) + 1 {
+		candidate := cmd_loader.MergeEnv(saved, inherited, cmd_loader.MergeEnvConfig{ConflictPriority: "incoming"})
+		expanded, err := cmd_loader.ExpandEnv(candidate)
+		if err != nil {
+			return nil, fmt.Errorf("error expanding %s: %w", env, err)
+		}
+		got := valuesByKey(expanded)
+
+		fixed := false
+		for i, ev := range saved {
+			want, ok := expected[ev.Key]
+			if ok && got[ev.Key] != want {
+				saved[i].Val = want
+				saved[i].Literal = strings.Contains(want, "${")
+				fixed = true
+			}
+		}
+		if !fixed {
+			break
+		}
+	}
+
+	return saved, nil
+}
+
+func valuesByKey(values []types.EnvValue) map[string]string {
+	byKey := make(map[string]string, len(values))
+	for _, ev := range values {
+		byKey[ev.Key] = ev.Val
+	}
+	return byKey
+}
+
+func summarizeChanges(changes []cmd_loader.EnvChange) string {
+	counts := make(map[cmd_loader.ChangeKind]int)
+	for _, change := range changes {
+		counts[change.Kind]++
+	}
+	return fmt.Sprintf("%d added, %d changed, %d removed", counts[cmd_loader.KeyAdded], counts[cmd_loader.KeyChanged], counts[cmd_loader.KeyRemoved])
+}
+
+func joinSources(sources []string) string {
+	if len(sources) == 1 {
+		return sources[0]
+	}
+	return fmt.Sprintf("%d targets", len(sources))
+}
+
+// recordTargets remembers what the targets hold now, so they no longer count
+// as drifted. Keys kept from before the last swap stay out of the env.
+func recordTargets(project *types.ProjectDir, written map[string][]byte) error {
+	for path, data := range written {
+		if err := recordEnvFile(project.ProjectName, path, data, filehandler.KeptKeys(project, path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func recordEnvFile(projectName, path string, data []byte, kept []string) error {
+	if err := filehandler.RecordEnvFile(projectName, path, data, kept); err != nil {
+		return fmt.Errorf("error updating project map: %w", err)
+	}
+	return nil
+}
@@ -2,90 +2,232 @@ package cmd_setter
 
 import (
 	"fmt"
-	"os"
-	"sort"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/reduan2660/swapenv/internal/cmd_loader"
 	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
 )
 
-func Set(env string, replace bool, skipCommon bool, versionStr string, nowrap bool) error {
+type SetOptions struct {
+	ResolveOptions
+	Replace bool // replace the existing .env instead of merging into it
+	NoWrap  bool // don't wrap values with special characters in quotes
+	Force   bool // overwrite targets even if they were edited since the last swap
+	Save    bool // save edits made to the targets since the last swap before swapping
+	Managed bool // write into a managed block, see filehandler.ReplaceManagedBlock
+	NoHooks bool // don't run the pre_swap and post_swap hooks
+	DryRun  bool // print what would change without writing anything
+	Reveal  bool // show values in the dry run instead of masking them
+
+	Confirmed string        // protected envs confirmed up front (--yes-i-mean), comma separated
+	For       time.Duration // swap back to the current env after this long
+}
+
+func Set(env string, opts SetOptions) error {
+
+	projectName, _, localDirectory, _, _, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: false})
+	if err != nil {
+		return err
+	}
+
+	// the base env is a layer under the others, without their keys it isn't a
+	// complete env to swap to
+	if env == filehandler.BaseEnv {
+		return fmt.Errorf("%s is the base env the others inherit from and can't be swapped to on its own, swap to an env that extends it", env)
+	}
+
+	return swapProject(projectName, localDirectory, env, opts)
+}
 
-	projectName, _, _, _, projectPath, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: false})
+// swapProject writes env to the targets of the project in localDirectory.
+func swapProject(projectName, localDirectory, env string, opts SetOptions) error {
+	cfg, err := filehandler.ReadProjectConfig(localDirectory)
 	if err != nil {
 		return err
 	}
 
-	if versionStr != "" {
-		version, err := filehandler.ResolveVersion(projectName, versionStr)
+	// a revealing dry run prints the env's values, as good as writing them out
+	if !opts.DryRun || opts.Reveal {
+		if err := filehandler.ConfirmProtected(localDirectory, []string{env}, opts.Confirmed); err != nil {
+			return err
+		}
+	}
+
+	if !opts.DryRun {
+		if !opts.NoHooks {
+			if err := checkHooksApproved(localDirectory); err != nil {
+				return err
+			}
+		}
+		if err := checkDrift(projectName, localDirectory, cfg.Targets, opts); err != nil {
+			return err
+		}
+	}
+
+	incomingEnvValues, err := ResolveEnv(projectName, "", env, opts.ResolveOptions)
+	if err != nil {
+		return err
+	}
+
+	swap, err := swapInfo(projectName, env, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		plans, err := planTargets(localDirectory, cfg, swap, incomingEnvValues, opts)
 		if err != nil {
 			return err
 		}
-		projectPath, err = filehandler.GetVersionFilePath(projectName, version)
+		drifted, err := driftedTargets(projectName, localDirectory, cfg.Targets)
 		if err != nil {
 			return err
 		}
+		printPlan(swap, plans, drifted, opts)
+		return nil
 	}
 
-	incomingEnvValues, err := filehandler.ReadProjectEnv(projectPath, env)
+	lease, err := nextLease(projectName, env, opts.For)
 	if err != nil {
 		return err
 	}
 
-	sort.Slice(incomingEnvValues, func(i, j int) bool {
-		return incomingEnvValues[i].Order < incomingEnvValues[j].Order
-	})
+	envCfg, _ := filehandler.EnvConfigFor(cfg, env)
+	if !opts.NoHooks {
+		if err := runHooks("pre_swap", localDirectory, slices.Concat(cfg.PreSwap, envCfg.PreSwap), swap); err != nil {
+			return fmt.Errorf("swap to %s aborted: %w", env, err)
+		}
+	}
 
-	if env == "common" {
-		// TODO: define behaviour
-		return fmt.Errorf("setting to common isnt allowed")
+	// planned after pre_swap, which may write to the targets itself
+	plans, err := planTargets(localDirectory, cfg, swap, incomingEnvValues, opts)
+	if err != nil {
+		return err
 	}
 
-	if !skipCommon {
-		commonEnvValues, err := filehandler.ReadProjectEnv(projectPath, "common")
+	for _, plan := range plans {
+		if err := applyTarget(projectName, plan); err != nil {
+			return err
+		}
+		if len(plans) > 1 {
+			fmt.Printf("wrote %s\n", plan.Target.Path)
+		}
+	}
 
-		if err == nil {
-			sort.Slice(commonEnvValues, func(i, j int) bool {
-				return commonEnvValues[i].Order < commonEnvValues[j].Order
-			})
+	if err := filehandler.UpdateCurrentEnv(projectName, env); err != nil {
+		return fmt.Errorf("error updating current env: %w", err)
+	}
 
-			// merge incoming (dev) with common: dev order first, dev values win for conflicts
-			incomingEnvValues = cmd_loader.MergeEnv(commonEnvValues, incomingEnvValues, cmd_loader.MergeEnvConfig{
-				ConflictPriority: "current",
-			})
-		}
+	if err := filehandler.SetLease(projectName, lease); err != nil {
+		return fmt.Errorf("error updating project map: %w", err)
 	}
 
-	envFilePath := ".env" // TODO: consider parent
+	fmt.Printf("Swapped environment to: %v\n", env)
+	if lease != nil {
+		fmt.Printf("reverting to %s in %s\n", LeaseTarget(lease), filehandler.FormatRemaining(opts.For))
+		if err := startWatcher(localDirectory, projectName, lease.ExpiresAt); err != nil {
+			fmt.Printf("%v, the swap is reverted by the next swapenv command after it expires\n", err)
+		}
+	}
 
-	if _, err := os.Stat(envFilePath); os.IsNotExist(err) {
-		if err := os.WriteFile(envFilePath, []byte{}, 0644); err != nil {
+	if !opts.NoHooks {
+		if err := runHooks("post_swap", localDirectory, slices.Concat(cfg.PostSwap, envCfg.PostSwap), swap); err != nil {
 			return err
 		}
 	}
+	return nil
+}
+
+// swapInfo describes a swap of the project to env: the env swapped from and
+// the version env is read from.
+func swapInfo(projectName, env string, opts SetOptions) (SwapInfo, error) {
+	swap := SwapInfo{Project: projectName, ToEnv: env}
 
-	curEnvFile, err := os.ReadFile(envFilePath)
+	project, err := filehandler.FindProjectByName(projectName)
 	if err != nil {
-		return err
+		return swap, fmt.Errorf("error reading project map: %w", err)
+	}
+	if project != nil {
+		swap.FromEnv = project.CurrentEnv
+		swap.Version = filehandler.EnvVersion(project, env)
 	}
 
+	if opts.Version != "" {
+		if swap.Version, err = filehandler.ResolveVersion(projectName, opts.Version); err != nil {
+			return swap, err
+		}
+	}
+
+	return swap, nil
+}
+
+// mergeEnvFile merges the incoming env into the whole file, keys only the
+// previous env had are kept unless Replace is set.
+func mergeEnvFile(curEnvFile []byte, incoming []types.EnvValue, opts SetOptions) ([]byte, error) {
 	curEnvValues, err := cmd_loader.ParseEnv(curEnvFile)
 	if err != nil {
-		return fmt.Errorf("error parsing .env: %w", err)
+		return nil, err
 	}
 
-	mergedEnv := cmd_loader.MergeEnv(incomingEnvValues, curEnvValues, cmd_loader.MergeEnvConfig{
-		Replace:          replace,
+	mergedEnv := cmd_loader.MergeEnv(incoming, curEnvValues, cmd_loader.MergeEnvConfig{
+		Replace:          opts.Replace,
 		ConflictPriority: "incoming",
 	})
-	if err := filehandler.WriteEnv(mergedEnv, envFilePath, !nowrap); err != nil {
-		return fmt.Errorf("error writing .env: %w", err)
+	return []byte(filehandler.RenderEnv(mergedEnv, !opts.NoWrap)), nil
+}
+
+// writeManagedBlock replaces the managed block of the file with the incoming
+// env, nothing outside the block is touched.
+func writeManagedBlock(swap SwapInfo, curEnvFile []byte, incoming []types.EnvValue, opts SetOptions) ([]byte, error) {
+	header := filehandler.ManagedBlockHeader(swap.ToEnv, swap.Version)
+	return filehandler.ReplaceManagedBlock(curEnvFile, header, filehandler.RenderEnv(incoming, !opts.NoWrap))
+}
+
+// checkDrift stops a swap from overwriting edits made to the targets since
+// the last swap, unless they are saved first or discarded with Force.
+func checkDrift(projectName, localDirectory string, targets []types.EnvTarget, opts SetOptions) error {
+	if opts.Force {
+		return nil
 	}
 
-	if err := filehandler.UpdateCurrentEnv(projectName, env); err != nil {
-		return fmt.Errorf("error updating current env: %w", err)
+	drifted, err := driftedTargets(projectName, localDirectory, targets)
+	if err != nil || len(drifted) == 0 {
+		return err
 	}
 
-	fmt.Printf("Swapped environment to: %v\n", env)
-	return nil
+	if opts.Save {
+		return Save(SaveOptions{})
+	}
+
+	current, err := filehandler.ReadActiveEnv(localDirectory)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%s changed since the last swap to %s (see swapenv status), use --save to store the edits in %s or --force to overwrite them", strings.Join(drifted, ", "), current, current)
+}
+
+// driftedTargets lists the targets edited since the last swap.
+func driftedTargets(projectName, localDirectory string, targets []types.EnvTarget) ([]string, error) {
+	project, err := filehandler.FindProjectByName(projectName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading project map: %w", err)
+	}
+	if project == nil {
+		return nil, nil
+	}
+
+	var drifted []string
+	for _, target := range targets {
+		isDrifted, err := filehandler.IsEnvFileDrifted(project, TargetPath(localDirectory, target))
+		if err != nil {
+			return nil, fmt.Errorf("error checking %s: %w", target.Path, err)
+		}
+		if isDrifted {
+			drifted = append(drifted, target.Path)
+		}
+	}
+	return drifted, nil
 }
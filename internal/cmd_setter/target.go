@@ -0,0 +1,69 @@
+package cmd_setter
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+// TargetPath is where a target is written, targets are relative to the
+// project directory and ReadProjectConfig keeps them inside it.
+func TargetPath(localDirectory string, target types.EnvTarget) string {
+	return filepath.Join(localDirectory, target.Path)
+}
+
+// TargetValues picks and renames the values of an env for a target.
+func TargetValues(target types.EnvTarget, values []types.EnvValue) []types.EnvValue {
+	renames := make(map[string]string, len(target.Rename))
+	for _, rule := range target.Rename {
+		renames[rule.From] = rule.To
+	}
+
+	picked := make([]types.EnvValue, 0, len(values))
+	for _, ev := range values {
+		switch to, ok := renames[ev.Key]; {
+		case ok:
+			ev.Key = to
+		case target.Prefix == "":
+		case strings.HasPrefix(ev.Key, target.Prefix):
+			if target.StripPrefix {
+				ev.Key = strings.TrimPrefix(ev.Key, target.Prefix)
+			}
+		default:
+			continue
+		}
+		picked = append(picked, ev)
+	}
+
+	return picked
+}
+
+// EditedValues drops the keys the last swap kept in a target from another env,
+// leaving what the env wrote there and what was added since.
+func EditedValues(project *types.ProjectDir, target types.EnvTarget, path string, env, working []types.EnvValue) []types.EnvValue {
+	kept := filehandler.KeptKeys(project, path)
+	if len(kept) == 0 {
+		return working
+	}
+
+	expected := TargetValues(target, env)
+	return slices.DeleteFunc(slices.Clone(working), func(ev types.EnvValue) bool {
+		return slices.Contains(kept, ev.Key) && !slices.ContainsFunc(expected, func(v types.EnvValue) bool { return v.Key == ev.Key })
+	})
+}
+
+// StoredKey maps a key as written in a target back to the key in the store.
+func StoredKey(target types.EnvTarget, key string) string {
+	for _, rule := range target.Rename {
+		if rule.To == key {
+			return rule.From
+		}
+	}
+	if target.Prefix != "" && target.StripPrefix {
+		return target.Prefix + key
+	}
+	return key
+}
@@ -21,7 +21,7 @@ type wsMessage struct {
 	Message string `json:"message,omitempty"`
 }
 
-func Share(serverURL, projectName, envName, versionStr string) error {
+func Share(serverURL, projectName, envName, versionStr, confirmed string) error {
 	if !api.IsLoggedIn() {
 		fmt.Println("Not logged in. Logging in...")
 		if err := cmd_login.Login(serverURL); err != nil {
@@ -80,6 +80,10 @@ func Share(serverURL, projectName, envName, versionStr string) error {
 		envNames = []string{envName}
 	}
 
+	if err := filehandler.ConfirmProtected(project.LocalPath, envNames, confirmed); err != nil {
+		return err
+	}
+
 	envMap := make(map[string][]types.EnvValue)
 	for _, name := range envNames {
 		envValues, err := filehandler.ReadProjectEnv(projectPath, name)
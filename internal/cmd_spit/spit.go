@@ -2,14 +2,16 @@ package cmd_spit
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"slices"
 
 	"github.com/reduan2660/swapenv/internal/cmd_loader"
 	"github.com/reduan2660/swapenv/internal/filehandler"
 )
 
-func Spit(envPattern, versionStr string) error {
-	projectName, _, _, _, projectPath, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
+func Spit(envPattern, versionStr, confirmed string) error {
+	projectName, _, localDirectory, _, projectPath, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
 	if err != nil {
 		return err
 	}
@@ -43,13 +45,43 @@ func Spit(envPattern, versionStr string) error {
 		targetEnvs = []string{envPattern}
 	}
 
+	if err := filehandler.ConfirmProtected(localDirectory, targetEnvs, confirmed); err != nil {
+		return err
+	}
+
+	cfg, err := filehandler.ReadProjectConfig(localDirectory)
+	if err != nil {
+		return err
+	}
+
+	// spit writes back where load reads from
+	pattern, err := cmd_loader.CompilePattern(cfg.LoadPatterns[0])
+	if err != nil {
+		return err
+	}
+
 	for _, envName := range targetEnvs {
-		envValues, err := filehandler.ReadProjectEnv(projectPath, envName)
+		envPath := projectPath
+		if versionStr == "" {
+			if envPath, err = filehandler.GetEnvVersionFilePath(projectName, envName); err != nil {
+				return err
+			}
+		}
+
+		envValues, err := filehandler.ReadProjectEnv(envPath, envName)
 		if err != nil {
 			return fmt.Errorf("error reading %s: %w", envName, err)
 		}
 
-		outputFile := fmt.Sprintf(".%s.env", envName)
+		outputFile := pattern.Render(envName)
+		if err := filehandler.InProject(localDirectory, outputFile); err != nil {
+			return fmt.Errorf("can't spit %s: %w", envName, err)
+		}
+		if dir := filepath.Dir(outputFile); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+		}
 		if err := filehandler.WriteEnv(envValues, outputFile, false); err != nil {
 			return fmt.Errorf("error writing %s: %w", outputFile, err)
 		}
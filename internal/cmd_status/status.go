@@ -0,0 +1,90 @@
+package cmd_status
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/cmd_setter"
+	"github.com/reduan2660/swapenv/internal/cmd_version"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+)
+
+type StatusOptions struct {
+	Reveal bool // show values instead of masking them
+}
+
+// Status compares each target file with the active env merged with common,
+// as swapenv to would write it.
+func Status(opts StatusOptions) error {
+	projectName, _, localDirectory, _, _, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		fmt.Println("no project under current directory, use swapenv load to initiate.")
+		return nil
+	}
+
+	project, err := filehandler.FindProjectByName(projectName)
+	if err != nil {
+		return fmt.Errorf("error reading project map: %w", err)
+	}
+	if project == nil || project.CurrentEnv == "" {
+		fmt.Println("no active environment, use swapenv to <env> to set one.")
+		return nil
+	}
+
+	cfg, err := filehandler.ReadProjectConfig(localDirectory)
+	if err != nil {
+		return err
+	}
+
+	env := project.CurrentEnv
+	fmt.Printf("on %s (v%d)\n", env, filehandler.EnvVersion(project, env))
+
+	stored, err := cmd_setter.ResolveEnv(projectName, "", env, cmd_setter.ResolveOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, target := range cfg.Targets {
+		path := cmd_setter.TargetPath(localDirectory, target)
+
+		working, _, err := cmd_setter.ReadTarget(path)
+		if os.IsNotExist(err) {
+			fmt.Printf("%s not found, use swapenv to %s to write it\n", target.Path, env)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		drifted, err := filehandler.IsEnvFileDrifted(project, path)
+		if err != nil {
+			return fmt.Errorf("error checking %s: %w", target.Path, err)
+		}
+
+		// keys the last swap kept from the previous env aren't part of this one
+		working = cmd_setter.EditedValues(project, target, path, stored, working)
+		changes := cmd_loader.DiffEnv(cmd_setter.TargetValues(target, stored), working)
+		if !opts.Reveal {
+			changes = cmd_loader.MaskChanges(changes)
+		}
+
+		if len(changes) == 0 {
+			fmt.Printf("%s matches %s\n", target.Path, env)
+			continue
+		}
+
+		fmt.Printf("%s differs from %s:\n", target.Path, env)
+		cmd_version.PrintChanges(changes)
+
+		if drifted {
+			fmt.Printf("%s was edited since the last swap, swapenv to won't overwrite it without --save or --force\n", target.Path)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package cmd_store
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/reduan2660/swapenv/internal/filehandler"
+)
+
+func Status() error {
+	cfg, err := filehandler.ReadStoreConfig()
+	if err != nil {
+		return err
+	}
+
+	sealed, plain := 0, 0
+	err = forEachVersionFile(func(path string, data []byte) error {
+		if filehandler.IsSealed(data) {
+			sealed++
+		} else {
+			plain++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if cfg.Encrypted {
+		fmt.Printf("store: encrypted (%s)\n", cfg.KDF)
+		fmt.Printf("key:   %s\n", filehandler.StoreKeySource())
+	} else {
+		fmt.Println("store: plaintext")
+	}
+	fmt.Printf("files: %d sealed, %d plaintext\n", sealed, plain)
+	return nil
+}
+
+// Encrypt seals every plaintext version file. Running it again on an encrypted
+// store picks up files an interrupted run left behind.
+func Encrypt() error {
+	cfg, err := filehandler.ReadStoreConfig()
+	if err != nil {
+		return err
+	}
+
+	var key []byte
+	if cfg.Encrypted {
+		if key, err = filehandler.UnlockStore(); err != nil {
+			return err
+		}
+	} else {
+		if cfg, key, err = filehandler.CreateStoreKey(); err != nil {
+			return err
+		}
+		// written first so files sealed by a partial run stay readable
+		if err := filehandler.WriteStoreConfig(cfg); err != nil {
+			return fmt.Errorf("error writing store config: %w", err)
+		}
+	}
+
+	count := 0
+	err = forEachVersionFile(func(path string, data []byte) error {
+		if filehandler.IsSealed(data) {
+			return nil
+		}
+
+		sealed, err := filehandler.SealWithKey(data, key)
+		if err != nil {
+			return err
+		}
+		if err := filehandler.WriteFileAtomic(path, sealed, 0600, false); err != nil {
+			return fmt.Errorf("error sealing %s: %w", path, err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("store encrypted, sealed %d version files\n", count)
+	return nil
+}
+
+// Decrypt opens every sealed version file and returns the store to plaintext.
+func Decrypt() error {
+	cfg, err := filehandler.ReadStoreConfig()
+	if err != nil {
+		return err
+	}
+	if !cfg.Encrypted {
+		fmt.Println("store is not encrypted")
+		return nil
+	}
+
+	key, err := filehandler.UnlockStore()
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	err = forEachVersionFile(func(path string, data []byte) error {
+		if !filehandler.IsSealed(data) {
+			return nil
+		}
+
+		opened, err := filehandler.OpenWithKey(data, key)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := filehandler.WriteFileAtomic(path, opened, 0600, false); err != nil {
+			return fmt.Errorf("error writing %s: %w", path, err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := filehandler.RemoveStoreConfig(); err != nil {
+		return fmt.Errorf("error removing store config: %w", err)
+	}
+
+	fmt.Printf("store decrypted, opened %d version files\n", count)
+	return nil
+}
+
+// forEachVersionFile calls fn with the raw content of every version file of
+// every project in the map.
+func forEachVersionFile(fn func(path string, data []byte) error) error {
+	dirs, err := filehandler.ReadProjectDirs()
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		versions, err := filehandler.ListVersions(dir.ProjectName)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range versions {
+			path, err := filehandler.GetVersionFilePath(dir.ProjectName, v)
+			if err != nil {
+				return err
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			if err := fn(path, data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package cmd_version
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+type DiffOptions struct {
+	Env    string // limit the diff to one env
+	Reveal bool   // show values instead of masking them
+	Format string // text or json
+
+	Confirmed string // protected envs confirmed up front (--yes-i-mean), comma separated
+}
+
+type envDiff struct {
+	Env     string                 `json:"env"`
+	Status  string                 `json:"status,omitempty"` // added or removed when the env exists on one side only
+	Changes []cmd_loader.EnvChange `json:"changes"`
+}
+
+type versionDiff struct {
+	From int       `json:"from"`
+	To   int       `json:"to"`
+	Envs []envDiff `json:"envs"`
+}
+
+func Diff(fromStr, toStr string, opts DiffOptions) error {
+	projectName, _, localDirectory, _, _, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		fmt.Println("no project under current directory, use swapenv load to initiate.")
+		return nil
+	}
+
+	if opts.Format == "" {
+		opts.Format = "text"
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return fmt.Errorf("unknown format '%s', available: text, json", opts.Format)
+	}
+
+	from, err := filehandler.ResolveVersion(projectName, fromStr)
+	if err != nil {
+		return err
+	}
+	to, err := filehandler.ResolveVersion(projectName, toStr)
+	if err != nil {
+		return err
+	}
+
+	fromEnvs, err := readVersionEnvs(projectName, from)
+	if err != nil {
+		return err
+	}
+	toEnvs, err := readVersionEnvs(projectName, to)
+	if err != nil {
+		return err
+	}
+
+	envNames := envNamesOf(fromEnvs, toEnvs)
+	if opts.Env != "" {
+		if !slices.Contains(envNames, opts.Env) {
+			return fmt.Errorf("environment '%s' not found in v%d or v%d", opts.Env, from, to)
+		}
+		envNames = []string{opts.Env}
+	}
+
+	result := versionDiff{From: from, To: to, Envs: make([]envDiff, 0)}
+	for _, name := range envNames {
+		fromValues, inFrom := fromEnvs[name]
+		toValues, inTo := toEnvs[name]
+
+		diff := envDiff{Env: name, Changes: cmd_loader.DiffEnv(fromValues, toValues)}
+		switch {
+		case !inFrom:
+			diff.Status = "added"
+		case !inTo:
+			diff.Status = "removed"
+		}
+		if !opts.Reveal {
+			diff.Changes = cmd_loader.MaskChanges(diff.Changes)
+		}
+		if len(diff.Changes) > 0 || diff.Status != "" {
+			result.Envs = append(result.Envs, diff)
+		}
+	}
+
+	// revealed changes print the values of every env listed
+	if opts.Reveal {
+		names := make([]string, len(result.Envs))
+		for i, diff := range result.Envs {
+			names[i] = diff.Env
+		}
+		if err := filehandler.ConfirmProtected(localDirectory, names, opts.Confirmed); err != nil {
+			return err
+		}
+	}
+
+	if opts.Format == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printDiff(result)
+	return nil
+}
+
+func printDiff(result versionDiff) {
+	if len(result.Envs) == 0 {
+		fmt.Printf("no differences between v%d and v%d\n", result.From, result.To)
+		return
+	}
+
+	fmt.Printf("v%d → v%d\n", result.From, result.To)
+	for _, diff := range result.Envs {
+		status := ""
+		if diff.Status != "" {
+			status = fmt.Sprintf(" (env %s)", diff.Status)
+		}
+		fmt.Printf("\n%s%s\n", diff.Env, status)
+		PrintChanges(diff.Changes)
+	}
+}
+
+// PrintChanges prints one line per change, prefixed with +, - or ~.
+func PrintChanges(changes []cmd_loader.EnvChange) {
+	for _, change := range changes {
+		switch change.Kind {
+		case cmd_loader.KeyAdded:
+			fmt.Printf("  + %s=%s\n", change.Key, change.New)
+		case cmd_loader.KeyRemoved:
+			fmt.Printf("  - %s\n", change.Key)
+		case cmd_loader.KeyChanged:
+			fmt.Printf("  ~ %s: %s → %s\n", change.Key, quoteEmpty(change.Old), quoteEmpty(change.New))
+		}
+	}
+}
+
+func quoteEmpty(val string) string {
+	if val == "" {
+		return `""`
+	}
+	return val
+}
+
+func readVersionEnvs(projectName string, version int) (map[string][]types.EnvValue, error) {
+	path, err := filehandler.GetVersionFilePath(projectName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	envs, err := filehandler.ReadProjectEnvs(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading v%d: %w", version, err)
+	}
+
+	return envs, nil
+}
+
+// envNamesOf returns the sorted union of env names on both sides.
+func envNamesOf(a, b map[string][]types.EnvValue) []string {
+	names := make([]string, 0, len(a)+len(b))
+	for name := range a {
+		names = append(names, name)
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
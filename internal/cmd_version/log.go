@@ -0,0 +1,102 @@
+package cmd_version
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+)
+
+type LogOptions struct {
+	Oneline bool
+}
+
+// Log prints the history of versions, newest first.
+func Log(opts LogOptions) error {
+	projectName, _, localDirectory, _, _, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		fmt.Println("no project under current directory, use swapenv load to initiate.")
+		return nil
+	}
+
+	project, err := filehandler.FindProjectByLocalPath(localDirectory)
+	if err != nil {
+		return err
+	}
+
+	versions, err := filehandler.ListVersions(projectName)
+	if err != nil {
+		return err
+	}
+	slices.Reverse(versions)
+
+	for i, v := range versions {
+		path, err := filehandler.GetVersionFilePath(projectName, v)
+		if err != nil {
+			return err
+		}
+
+		header, err := filehandler.ReadProjectHeader(path)
+		if err != nil {
+			return fmt.Errorf("error reading v%d: %w", v, err)
+		}
+
+		operation := header.Operation
+		if operation == "" {
+			operation = "-"
+		}
+		date := time.Unix(header.CreatedAt, 0)
+
+		labels := ""
+		if n, ok := project.VersionNames[strconv.Itoa(v)]; ok {
+			labels += fmt.Sprintf(" (%s)", n)
+		}
+
+		if opts.Oneline {
+			marker := "  "
+			if v == project.CurrentVersion {
+				marker = "* "
+			}
+			fmt.Printf("%sv%d %s %-7s %s%s\n", marker, v, date.Format("2006-01-02 15:04"), operation, header.Message, labels)
+			continue
+		}
+
+		if v == project.CurrentVersion {
+			labels += " [current]"
+		}
+		if v == project.LatestVersion {
+			labels += " [latest]"
+		}
+
+		envNames, err := filehandler.ListProjectEnv(path)
+		if err != nil {
+			return fmt.Errorf("error reading v%d: %w", v, err)
+		}
+		slices.Sort(envNames)
+
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("version %d%s\n", v, labels)
+		fmt.Printf("Operation: %s\n", operation)
+		fmt.Printf("Author:    %s\n", header.Owner)
+		fmt.Printf("Date:      %s\n", date.Format("Mon Jan 2 15:04:05 2006 -0700"))
+		if len(header.Source) > 0 {
+			fmt.Printf("Source:    %s\n", strings.Join(header.Source, ", "))
+		}
+		fmt.Printf("Envs:      %s\n", strings.Join(envNames, ", "))
+		if header.Message != "" {
+			fmt.Printf("\n    %s\n", strings.ReplaceAll(header.Message, "\n", "\n    "))
+		}
+	}
+
+	return nil
+}
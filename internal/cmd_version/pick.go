@@ -0,0 +1,116 @@
+package cmd_version
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+type PickOptions struct {
+	Env     string   // env to pick from, all envs of the version when empty
+	Keys    []string // keys to pick, whole envs when empty
+	Message string
+}
+
+// Pick applies keys or whole envs of an old version on top of the current
+// envs and stores the result as a new version.
+func Pick(versionStr string, opts PickOptions) error {
+	projectName, localOwner, localDirectory, homeDirectory, _, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		fmt.Println("no project under current directory, use swapenv load to initiate.")
+		return nil
+	}
+
+	version, err := filehandler.ResolveVersion(projectName, versionStr)
+	if err != nil {
+		return err
+	}
+
+	fromEnvs, err := readVersionEnvs(projectName, version)
+	if err != nil {
+		return err
+	}
+
+	if opts.Env != "" {
+		values, ok := fromEnvs[opts.Env]
+		if !ok {
+			return fmt.Errorf("environment '%s' not found in v%d", opts.Env, version)
+		}
+		fromEnvs = map[string][]types.EnvValue{opts.Env: values}
+	}
+
+	picked := fromEnvs
+	if len(opts.Keys) > 0 {
+		if picked, err = pickKeys(fromEnvs, opts.Keys); err != nil {
+			return fmt.Errorf("v%d: %w", version, err)
+		}
+	}
+
+	envs, err := filehandler.ReadCurrentEnvs(projectName)
+	if err != nil {
+		return err
+	}
+
+	envNames := make([]string, 0, len(picked))
+	for envName, values := range picked {
+		// whole envs are restored as they were, keys are merged into the current env
+		envs[envName] = cmd_loader.MergeEnv(values, envs[envName], cmd_loader.MergeEnvConfig{
+			Replace:          len(opts.Keys) == 0,
+			ConflictPriority: "incoming",
+		})
+		envNames = append(envNames, envName)
+	}
+	slices.Sort(envNames)
+
+	source := fmt.Sprintf("v%d %s", version, strings.Join(envNames, ","))
+	if len(opts.Keys) > 0 {
+		source += " " + strings.Join(opts.Keys, ",")
+	}
+
+	// the merged envs are a full snapshot, nothing left to fast forward
+	newVersion, err := cmd_loader.StoreEnvs(projectName, localOwner, localDirectory, homeDirectory, envs, cmd_loader.StoreOptions{
+		Snapshot:  true,
+		Operation: types.OpPick,
+		Source:    []string{source},
+		Message:   opts.Message,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("picked %s into v%d\n", source, newVersion)
+	return nil
+}
+
+// pickKeys keeps only keys from each env, dropping envs that have none of
+// them. Every key has to be found in at least one env.
+func pickKeys(envs map[string][]types.EnvValue, keys []string) (map[string][]types.EnvValue, error) {
+	picked := make(map[string][]types.EnvValue)
+	found := make(map[string]bool)
+
+	for envName, values := range envs {
+		for _, ev := range values {
+			if slices.Contains(keys, ev.Key) {
+				ev.Trailing = nil // end-of-file comments stay with the current env
+				picked[envName] = append(picked[envName], ev)
+				found[ev.Key] = true
+			}
+		}
+	}
+
+	for _, key := range keys {
+		if !found[key] {
+			return nil, fmt.Errorf("key '%s' not found", key)
+		}
+	}
+
+	return picked, nil
+}
@@ -0,0 +1,59 @@
+package cmd_version
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+)
+
+type PruneOptions struct {
+	DryRun bool
+}
+
+// Prune applies the retention policy now. With DryRun it only lists what
+// would be kept and deleted.
+func Prune(opts PruneOptions) error {
+	projectName, _, _, _, _, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		fmt.Println("no project under current directory, use swapenv load to initiate.")
+		return nil
+	}
+
+	if opts.DryRun {
+		decisions, err := filehandler.PlanPrune(projectName, time.Now())
+		if err != nil {
+			return err
+		}
+
+		toDelete := 0
+		for _, d := range decisions {
+			if d.Keep {
+				fmt.Printf("  keep    v%d  %s  (%s)\n", d.Version, d.Created.Format("2006-01-02 15:04"), d.Reason)
+			} else {
+				fmt.Printf("  delete  v%d  %s\n", d.Version, d.Created.Format("2006-01-02 15:04"))
+				toDelete++
+			}
+		}
+		fmt.Printf("would delete %d of %d versions\n", toDelete, len(decisions))
+		return nil
+	}
+
+	deleted, err := filehandler.PruneVersions(projectName)
+	for _, v := range deleted {
+		fmt.Printf("deleted v%d\n", v)
+	}
+	if err != nil {
+		return fmt.Errorf("error pruning versions: %w", err)
+	}
+
+	if len(deleted) == 0 {
+		fmt.Println("nothing to prune")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package cmd_version
+
+import (
+	"fmt"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+type RevertOptions struct {
+	Message string
+}
+
+// Revert copies an old version forward as a new latest version, keeping the
+// history in between instead of moving the current version pointer back.
+func Revert(versionStr string, opts RevertOptions) error {
+	projectName, localOwner, localDirectory, homeDirectory, _, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	if projectName == "" {
+		fmt.Println("no project under current directory, use swapenv load to initiate.")
+		return nil
+	}
+
+	version, err := filehandler.ResolveVersion(projectName, versionStr)
+	if err != nil {
+		return err
+	}
+
+	envs, err := readVersionEnvs(projectName, version)
+	if err != nil {
+		return err
+	}
+
+	newVersion, err := cmd_loader.StoreEnvs(projectName, localOwner, localDirectory, homeDirectory, envs, cmd_loader.StoreOptions{
+		Snapshot:  true,
+		Operation: types.OpRevert,
+		Source:    []string{fmt.Sprintf("v%d", version)},
+		Message:   opts.Message,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("reverted to v%d as v%d\n", version, newVersion)
+	return nil
+}
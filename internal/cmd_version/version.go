@@ -6,9 +6,10 @@ import (
 
 	"github.com/reduan2660/swapenv/internal/cmd_loader"
 	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
 )
 
-func Show() error {
+func Show(env string) error {
 	projectName, _, localDirectory, _, _, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
 	if err != nil {
 		return err
@@ -24,6 +25,16 @@ func Show() error {
 		return err
 	}
 
+	if env != "" {
+		version := filehandler.EnvVersion(project, env)
+		if version != project.CurrentVersion {
+			fmt.Printf("%s: v%d (pinned, project at v%d)\n", env, version, project.CurrentVersion)
+		} else {
+			fmt.Printf("%s: v%d (follows project)\n", env, version)
+		}
+		return nil
+	}
+
 	name := ""
 	if n, ok := project.VersionNames[strconv.Itoa(project.CurrentVersion)]; ok {
 		name = fmt.Sprintf(" (%s)", n)
@@ -33,7 +44,8 @@ func Show() error {
 	return nil
 }
 
-func Set(versionStr string) error {
+// Set switches the project to a version, or with env pins only that env.
+func Set(versionStr, env string) error {
 	projectName, _, _, _, _, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
 	if err != nil {
 		return err
@@ -49,6 +61,10 @@ func Set(versionStr string) error {
 		return err
 	}
 
+	if env != "" {
+		return pinEnv(projectName, env, version)
+	}
+
 	if err := filehandler.SetCurrentVersion(projectName, version); err != nil {
 		return err
 	}
@@ -128,7 +144,9 @@ func Rename(versionStr, name string) error {
 	return nil
 }
 
-func Rollback(steps int) error {
+// Rollback moves the project back by versions, or with env moves that env
+// back through the versions where it changed.
+func Rollback(steps int, env string) error {
 	projectName, _, localDirectory, _, _, err := cmd_loader.GetBasicInfo(cmd_loader.GetBasicInfoOptions{ReadOnly: true})
 	if err != nil {
 		return err
@@ -144,6 +162,10 @@ func Rollback(steps int) error {
 		return err
 	}
 
+	if env != "" {
+		return rollbackEnv(projectName, project, env, steps)
+	}
+
 	versions, err := filehandler.ListVersions(projectName)
 	if err != nil {
 		return err
@@ -174,3 +196,78 @@ func Rollback(steps int) error {
 	fmt.Printf("rolled back to v%d\n", newVersion)
 	return nil
 }
+
+func pinEnv(projectName, env string, version int) error {
+	path, err := filehandler.GetVersionFilePath(projectName, version)
+	if err != nil {
+		return err
+	}
+	if _, err := filehandler.ReadProjectEnv(path, env); err != nil {
+		return fmt.Errorf("v%d: %w", version, err)
+	}
+
+	if err := filehandler.PinEnvVersion(projectName, env, version); err != nil {
+		return err
+	}
+
+	fmt.Printf("switched %s to v%d\n", env, version)
+	return nil
+}
+
+func rollbackEnv(projectName string, project *types.ProjectDir, env string, steps int) error {
+	history, err := envHistory(projectName, env)
+	if err != nil {
+		return err
+	}
+
+	// the change that produced the env's content at the version in use
+	from := filehandler.EnvVersion(project, env)
+	idx := -1
+	for i, v := range history {
+		if v <= from {
+			idx = i
+		}
+	}
+	if idx == -1 {
+		return fmt.Errorf("environment '%s' not found in v%d", env, from)
+	}
+
+	target := history[max(idx-steps, 0)]
+	if err := filehandler.PinEnvVersion(projectName, env, target); err != nil {
+		return err
+	}
+
+	fmt.Printf("rolled %s back to v%d\n", env, target)
+	return nil
+}
+
+// envHistory lists the versions where env was created or changed, oldest first.
+func envHistory(projectName, env string) ([]int, error) {
+	versions, err := filehandler.ListVersions(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []int
+	var previous []types.EnvValue
+	found := false
+	for _, v := range versions {
+		path, err := filehandler.GetVersionFilePath(projectName, v)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := filehandler.ReadProjectEnv(path, env)
+		if err != nil {
+			found = false // missing here, so its next appearance is a change
+			continue
+		}
+
+		if !found || len(cmd_loader.DiffEnv(previous, values)) > 0 {
+			history = append(history, v)
+		}
+		previous, found = values, true
+	}
+
+	return history, nil
+}
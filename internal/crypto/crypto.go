@@ -4,13 +4,21 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/ecdh"
+	"crypto/pbkdf2"
 	"crypto/rand"
+	"crypto/sha256"
 	"errors"
 	"io"
 )
 
 const nonceSize = 24
 
+const (
+	KeySize       = 32
+	SaltSize      = 16
+	KDFIterations = 600000
+)
+
 func GenerateKeyPair() (*ecdh.PrivateKey, error) {
 	return ecdh.X25519().GenerateKey(rand.Reader)
 }
@@ -30,23 +38,11 @@ func Encrypt(data []byte, pub *ecdh.PublicKey) ([]byte, error) {
 		return nil, err
 	}
 
-	block, err := aes.NewCipher(shared)
-	if err != nil {
-		return nil, err
-	}
-
-	gcm, err := cipher.NewGCM(block)
+	ciphertext, err := Seal(data, shared)
 	if err != nil {
 		return nil, err
 	}
 
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, err
-	}
-
-	ciphertext := gcm.Seal(nonce, nonce, data, nil)
-
 	return append(ephemeral.PublicKey().Bytes(), ciphertext...), nil
 }
 
@@ -65,22 +61,59 @@ func Decrypt(encrypted []byte, priv *ecdh.PrivateKey) ([]byte, error) {
 		return nil, err
 	}
 
-	block, err := aes.NewCipher(shared)
+	return Open(encrypted[32:], shared)
+}
+
+// Seal encrypts data with a symmetric key using AES-GCM. The random nonce is
+// prepended to the ciphertext.
+func Seal(data, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
 		return nil, err
 	}
 
-	gcm, err := cipher.NewGCM(block)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// Open decrypts data produced by Seal.
+func Open(sealed, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
 		return nil, err
 	}
 
 	nonceSize := gcm.NonceSize()
-	ciphertext := encrypted[32:]
-	if len(ciphertext) < nonceSize {
+	if len(sealed) < nonceSize {
 		return nil, errors.New("ciphertext too short")
 	}
 
-	return gcm.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], nil)
+	return gcm.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+}
+
+// DeriveKey stretches a passphrase or key file content into a Seal key.
+func DeriveKey(secret, salt []byte, iterations int) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, string(secret), salt, iterations, KeySize)
+}
+
+// NewSalt returns a random salt for DeriveKey.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
 
+	return cipher.NewGCM(block)
 }
@@ -0,0 +1,190 @@
+package filehandler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+// The allow list maps a project's local path to the project name it was
+// approved for, so remapping a directory to another project needs a new approval.
+
+func GetAllowFilePath() (string, error) {
+	homeDir, err := GetBaseDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homeDir, "allow.json"), nil
+}
+
+func ReadAllowList() (map[string]string, error) {
+	allowPath, err := GetAllowFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	return readApprovals(allowPath)
+}
+
+func WriteAllowList(allowed map[string]string) error {
+	allowPath, err := GetAllowFilePath()
+	if err != nil {
+		return err
+	}
+
+	return writeApprovals(allowPath, allowed)
+}
+
+func IsAllowed(localPath, projectName string) (bool, error) {
+	allowed, err := ReadAllowList()
+	if err != nil {
+		return false, err
+	}
+
+	return allowed[localPath] == projectName, nil
+}
+
+func SetAllowed(localPath, projectName string, allow bool) error {
+	allowPath, err := GetAllowFilePath()
+	if err != nil {
+		return err
+	}
+
+	return withFileLock(allowPath, func() error {
+		allowed, err := ReadAllowList()
+		if err != nil {
+			return err
+		}
+
+		if allow {
+			allowed[localPath] = projectName
+		} else {
+			delete(allowed, localPath)
+		}
+
+		return WriteAllowList(allowed)
+	})
+}
+
+// Hooks in a project's .swapenv.yaml come with the repo, so they only run once
+// approved with `swapenv hooks approve`. The approval maps the local path to a
+// digest of the commands and lapses when they change. It is kept apart from
+// the allow list, which only covers the shell hook's auto-export.
+
+func GetHooksApprovalFilePath() (string, error) {
+	homeDir, err := GetBaseDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homeDir, "hooks.json"), nil
+}
+
+// ProjectHooks returns the pre_swap and post_swap hooks set in the project's
+// .swapenv.yaml, keyed by where they are set, e.g. envs.prod.post_swap.
+func ProjectHooks(localPath string) (map[string][]string, error) {
+	var cfg types.ProjectConfig
+	if err := readConfigFile(localPath, &cfg); err != nil {
+		return nil, err
+	}
+
+	hooks := make(map[string][]string)
+	add := func(name string, commands []string) {
+		if len(commands) > 0 {
+			hooks[name] = commands
+		}
+	}
+	add("pre_swap", cfg.PreSwap)
+	add("post_swap", cfg.PostSwap)
+	for name, env := range cfg.Envs {
+		add("envs."+name+".pre_swap", env.PreSwap)
+		add("envs."+name+".post_swap", env.PostSwap)
+	}
+
+	return hooks, nil
+}
+
+// ProjectHooksDigest hashes the hooks set in the project's .swapenv.yaml, or
+// returns "" when it sets none.
+func ProjectHooksDigest(localPath string) (string, error) {
+	hooks, err := ProjectHooks(localPath)
+	if err != nil || len(hooks) == 0 {
+		return "", err
+	}
+
+	// map keys are marshalled sorted, so the digest is stable
+	data, err := json.Marshal(hooks)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func IsHooksApproved(localPath, digest string) (bool, error) {
+	approvalPath, err := GetHooksApprovalFilePath()
+	if err != nil {
+		return false, err
+	}
+
+	approved, err := readApprovals(approvalPath)
+	if err != nil {
+		return false, err
+	}
+
+	return approved[localPath] == digest, nil
+}
+
+// SetHooksApproved approves the hooks with digest for localPath, "" revokes.
+func SetHooksApproved(localPath, digest string) error {
+	approvalPath, err := GetHooksApprovalFilePath()
+	if err != nil {
+		return err
+	}
+
+	return withFileLock(approvalPath, func() error {
+		approved, err := readApprovals(approvalPath)
+		if err != nil {
+			return err
+		}
+
+		if digest != "" {
+			approved[localPath] = digest
+		} else {
+			delete(approved, localPath)
+		}
+
+		return writeApprovals(approvalPath, approved)
+	})
+}
+
+func readApprovals(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	approved := make(map[string]string)
+	if err := json.Unmarshal(data, &approved); err != nil {
+		return nil, err
+	}
+
+	return approved, nil
+}
+
+func writeApprovals(path string, approved map[string]string) error {
+	data, err := json.MarshalIndent(approved, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(path, data, 0644, false)
+}
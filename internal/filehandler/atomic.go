@@ -0,0 +1,139 @@
+package filehandler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	tempPrefix = ".swapenv-tmp-"
+	backupExt  = ".bak"
+	lockExt    = ".lock"
+)
+
+// staleTempAge is how old a leftover temp file has to be before it is treated
+// as the remains of an interrupted write rather than one still in progress.
+const staleTempAge = time.Minute
+
+// WriteFileAtomic writes data to a temp file next to path, syncs it and renames
+// it over path, so readers only ever see the old or the new content. With
+// backup set, the previous content is kept at path + ".bak".
+func WriteFileAtomic(path string, data []byte, perm os.FileMode, backup bool) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, tempPrefix+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("error creating temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error syncing temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error closing temp file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("error setting permissions: %w", err)
+	}
+
+	if backup {
+		if err := backupFile(path, perm); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("error replacing %s: %w", filepath.Base(path), err)
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// backupFile copies the current content of path to path + ".bak". A missing
+// or empty file is not backed up, so a truncated file never replaces a good backup.
+func backupFile(path string, perm os.FileMode) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) || (err == nil && len(data) == 0) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading %s for backup: %w", filepath.Base(path), err)
+	}
+
+	return WriteFileAtomic(path+backupExt, data, perm, false)
+}
+
+// readWithBackup reads path and checks it with valid. When path is missing,
+// empty or fails the check, the backup is used instead if it is usable.
+func readWithBackup(path string, valid func([]byte) error) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var readErr error
+	if err == nil {
+		if readErr = valid(data); readErr == nil {
+			return data, nil
+		}
+	}
+
+	backup, backupErr := os.ReadFile(path + backupExt)
+	if backupErr != nil || valid(backup) != nil {
+		if readErr != nil {
+			return nil, fmt.Errorf("%s is corrupt and no usable backup was found: %w", filepath.Base(path), readErr)
+		}
+		return nil, err // not exist
+	}
+
+	fmt.Fprintf(os.Stderr, "warning: %s was incomplete, recovered from %s\n", filepath.Base(path), filepath.Base(path)+backupExt)
+	return backup, nil
+}
+
+// cleanupTempFiles removes temp files left in dir by writes that never reached
+// the rename step.
+func cleanupTempFiles(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), tempPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < staleTempAge {
+			continue
+		}
+		os.Remove(filepath.Join(dir, entry.Name()))
+	}
+}
+
+// withFileLock holds an exclusive advisory lock on path + ".lock" while fn runs.
+func withFileLock(path string, fn func() error) error {
+	lock, err := os.OpenFile(path+lockExt, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening lock file: %w", err)
+	}
+	defer lock.Close()
+
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("error locking %s: %w", filepath.Base(path), err)
+	}
+	defer unlockFile(lock)
+
+	cleanupTempFiles(filepath.Dir(path))
+	return fn()
+}
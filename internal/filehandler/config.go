@@ -0,0 +1,127 @@
+package filehandler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/types"
+	"github.com/spf13/viper"
+)
+
+// ProjectConfigFile sits in a project's directory and overrides the global
+// config (default.yaml) for that project only.
+const ProjectConfigFile = ".swapenv.yaml"
+
+var DefaultLoadPatterns = []string{".{env}.env"}
+
+var DefaultTargets = []types.EnvTarget{{Path: ".env"}}
+
+// ReadProjectConfig layers the project's .swapenv.yaml over the global config.
+// Keys missing from both fall back to the built-in defaults.
+func ReadProjectConfig(localPath string) (types.ProjectConfig, error) {
+	var cfg types.ProjectConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return cfg, fmt.Errorf("error reading config: %w", err)
+	}
+
+	if err := readConfigFile(localPath, &cfg); err != nil {
+		return cfg, err
+	}
+
+	if len(cfg.LoadPatterns) == 0 {
+		cfg.LoadPatterns = DefaultLoadPatterns
+	}
+
+	if len(cfg.Targets) == 0 {
+		cfg.Targets = DefaultTargets
+	}
+	for _, target := range cfg.Targets {
+		if target.Path == "" {
+			return cfg, fmt.Errorf("error reading config: target without a path")
+		}
+		if err := CheckRelativePath(target.Path); err != nil {
+			return cfg, fmt.Errorf("error reading config: target %w", err)
+		}
+		for _, rule := range target.Rename {
+			if rule.From == "" || rule.To == "" {
+				return cfg, fmt.Errorf("error reading config: target %s has a rename without from or to", target.Path)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// CheckRelativePath rejects a path from the config that is absolute or uses
+// .., a committed .swapenv.yaml must not reach outside the project.
+func CheckRelativePath(path string) error {
+	slashed := filepath.ToSlash(path)
+	if filepath.IsAbs(path) || filepath.VolumeName(path) != "" || strings.HasPrefix(slashed, "/") {
+		return fmt.Errorf("%s must be relative to the project", path)
+	}
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." {
+			return fmt.Errorf("%s must not use ..", path)
+		}
+	}
+	return nil
+}
+
+// InProject makes sure path, absolute or relative to the working directory,
+// is inside the project at localPath, also once symlinked directories are
+// followed.
+func InProject(localPath, path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if !isInside(localPath, abs) {
+		return fmt.Errorf("%s is outside the project", path)
+	}
+
+	root, err := filepath.EvalSymlinks(localPath)
+	if err != nil {
+		return err
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(abs))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !isInside(root, filepath.Join(dir, filepath.Base(abs))) {
+		return fmt.Errorf("%s is outside the project", path)
+	}
+	return nil
+}
+
+func isInside(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// readConfigFile unmarshals the project's .swapenv.yaml, if there is one,
+// over cfg.
+func readConfigFile(localPath string, cfg *types.ProjectConfig) error {
+	if localPath == "" {
+		return nil
+	}
+
+	path := filepath.Join(localPath, ProjectConfigFile)
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+
+	projectViper := viper.New()
+	projectViper.SetConfigFile(path)
+	if err := projectViper.ReadInConfig(); err != nil {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+	if err := projectViper.Unmarshal(cfg); err != nil {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return nil
+}
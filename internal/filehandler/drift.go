@@ -0,0 +1,84 @@
+package filehandler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+func EnvFileChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordEnvFile remembers the content swapenv wrote to an env file, keyed by
+// its path inside the project, to notice later edits. With a managed block
+// only the block counts. kept are the keys left in the file that aren't part
+// of the env written to it.
+func RecordEnvFile(projectName, path string, data []byte, kept []string) error {
+	return updateProject(projectName, func(dir *types.ProjectDir) error {
+		key := envFileKey(dir, path)
+		if dir.EnvChecksums == nil {
+			dir.EnvChecksums = make(map[string]string)
+		}
+		dir.EnvChecksums[key] = managedChecksum(data)
+
+		if len(kept) == 0 {
+			delete(dir.EnvKept, key)
+			return nil
+		}
+		if dir.EnvKept == nil {
+			dir.EnvKept = make(map[string][]string)
+		}
+		dir.EnvKept[key] = kept
+		return nil
+	})
+}
+
+// KeptKeys returns the keys the last swap kept in the env file at path.
+func KeptKeys(dir *types.ProjectDir, path string) []string {
+	return dir.EnvKept[envFileKey(dir, path)]
+}
+
+// IsEnvFileDrifted reports whether the env file at path was changed since
+// swapenv last wrote it. Files swapenv never wrote, or that don't exist, have
+// nothing to lose and are not drifted.
+func IsEnvFileDrifted(dir *types.ProjectDir, path string) (bool, error) {
+	recorded, ok := dir.EnvChecksums[envFileKey(dir, path)]
+	if !ok {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return managedChecksum(data) != recorded, nil
+}
+
+// managedChecksum sums the part of data swapenv manages. A broken block
+// sums the whole file, which never matches and so counts as drifted.
+func managedChecksum(data []byte) string {
+	content, err := ManagedContent(data)
+	if err != nil {
+		return EnvFileChecksum(data)
+	}
+	return EnvFileChecksum(content)
+}
+
+func envFileKey(dir *types.ProjectDir, path string) string {
+	if dir.LocalPath == "" || !filepath.IsAbs(path) {
+		return filepath.ToSlash(filepath.Clean(path))
+	}
+	if rel, err := filepath.Rel(dir.LocalPath, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
@@ -4,39 +4,58 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/reduan2660/swapenv/internal/types"
 )
 
-func ReadProjectEnv(projectPath, envName string) ([]types.EnvValue, error) {
-	data, err := os.ReadFile(projectPath)
+// ProjectHeaderFields are the keys of a version file that hold metadata
+// rather than an env, so they can't be used as env names.
+var ProjectHeaderFields = []string{
+	"id", "owner", "localDirectory", "createdAt", "modifiedAt",
+	"operation", "source", "message",
+}
+
+// readProjectFile returns the project name and top-level keys of a version file
+func readProjectFile(projectPath string) (string, map[string]json.RawMessage, error) {
+	data, err := ReadVersionFile(projectPath)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
 	var outer map[string]json.RawMessage
 	if err := json.Unmarshal(data, &outer); err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
 	if len(outer) != 1 {
-		return nil, fmt.Errorf("invalid project JSON: expected 1 project, got %d", len(outer))
+		return "", nil, fmt.Errorf("invalid project JSON: expected 1 project, got %d", len(outer))
 	}
 
+	var name string
 	var innerData json.RawMessage
-	for _, data := range outer {
-		innerData = data
+	for key, data := range outer {
+		name, innerData = key, data
 		break
 	}
 
 	var inner map[string]json.RawMessage
 	if err := json.Unmarshal(innerData, &inner); err != nil {
+		return "", nil, err
+	}
+
+	return name, inner, nil
+}
+
+func ReadProjectEnv(projectPath, envName string) ([]types.EnvValue, error) {
+	_, inner, err := readProjectFile(projectPath)
+	if err != nil {
 		return nil, err
 	}
 
 	envData, exists := inner[envName]
-	if !exists {
+	if !exists || slices.Contains(ProjectHeaderFields, envName) {
 		return nil, fmt.Errorf("environment '%s' not found in project", envName)
 	}
 
@@ -49,55 +68,108 @@ func ReadProjectEnv(projectPath, envName string) ([]types.EnvValue, error) {
 }
 
 func ListProjectEnv(projectPath string) ([]string, error) {
-	data, err := os.ReadFile(projectPath)
+	_, inner, err := readProjectFile(projectPath)
 	if err != nil {
 		return nil, err
 	}
 
-	var outer map[string]json.RawMessage
-	if err := json.Unmarshal(data, &outer); err != nil {
+	envNames := make([]string, 0)
+	for key := range inner {
+		if !slices.Contains(ProjectHeaderFields, key) {
+			envNames = append(envNames, key)
+		}
+	}
+
+	return envNames, nil
+}
+
+// ReadProjectEnvs reads every env of a version file.
+func ReadProjectEnvs(projectPath string) (map[string][]types.EnvValue, error) {
+	envNames, err := ListProjectEnv(projectPath)
+	if err != nil {
 		return nil, err
 	}
 
-	if len(outer) != 1 {
-		return nil, fmt.Errorf("invalid project JSON: expected 1 project, got %d", len(outer))
+	envs := make(map[string][]types.EnvValue, len(envNames))
+	for _, name := range envNames {
+		values, err := ReadProjectEnv(projectPath, name)
+		if err != nil {
+			return nil, err
+		}
+		envs[name] = values
 	}
 
-	var innerData json.RawMessage
-	for _, data := range outer {
-		innerData = data
-		break
+	return envs, nil
+}
+
+// ReadCurrentEnvs reads every env of a project from the version it is read
+// from, so pinned envs come from their pinned version.
+func ReadCurrentEnvs(projectName string) (map[string][]types.EnvValue, error) {
+	dir, err := FindProjectByName(projectName)
+	if err != nil {
+		return nil, err
+	}
+	if dir == nil {
+		return nil, fmt.Errorf("project not found: %s", projectName)
 	}
 
-	var inner map[string]json.RawMessage
-	if err := json.Unmarshal(innerData, &inner); err != nil {
+	currentPath, err := GetVersionFilePath(projectName, dir.CurrentVersion)
+	if err != nil {
 		return nil, err
 	}
 
-	excludeFields := map[string]bool{
-		"id": true, "owner": true, "localDirectory": true,
-		"createdAt": true, "modifiedAt": true,
+	envs, err := ReadProjectEnvs(currentPath)
+	if err != nil {
+		return nil, err
 	}
 
-	envNames := make([]string, 0)
-	for key := range inner {
-		if !excludeFields[key] {
-			envNames = append(envNames, key)
+	for env, version := range dir.EnvVersions {
+		path, err := GetVersionFilePath(projectName, version)
+		if err != nil {
+			return nil, err
+		}
+		if envs[env], err = ReadProjectEnv(path, env); err != nil {
+			return nil, fmt.Errorf("v%d: %w", version, err)
 		}
 	}
 
-	return envNames, nil
+	return envs, nil
 }
 
-func WriteProject(directory, filePath string, file_content []byte) error {
-	if err := os.MkdirAll(directory, 0755); err != nil { // TODO - consider 0700 - rething permissions
-		return err
+// ReadProjectHeader reads a version file's metadata, without its envs.
+func ReadProjectHeader(projectPath string) (types.Project, error) {
+	var project types.Project
+
+	name, inner, err := readProjectFile(projectPath)
+	if err != nil {
+		return project, err
 	}
 
-	return os.WriteFile(filePath, file_content, 0644)
+	header := make(map[string]json.RawMessage, len(ProjectHeaderFields))
+	for _, field := range ProjectHeaderFields {
+		if val, ok := inner[field]; ok {
+			header[field] = val
+		}
+	}
+
+	data, err := json.Marshal(header)
+	if err != nil {
+		return project, err
+	}
+	if err := json.Unmarshal(data, &project); err != nil {
+		return project, fmt.Errorf("invalid project header: %w", err)
+	}
+
+	project.Name = name
+	return project, nil
 }
 
 func WriteEnv(envValues []types.EnvValue, filepath string, wrapSpecialChars bool) error {
+	return os.WriteFile(filepath, []byte(RenderEnv(envValues, wrapSpecialChars)), 0644)
+}
+
+// RenderEnv formats envValues as dotenv, comments and spacing included.
+func RenderEnv(envValues []types.EnvValue, wrapSpecialChars bool) string {
 	var builder strings.Builder
 
 	for _, ev := range envValues {
@@ -105,12 +177,23 @@ func WriteEnv(envValues []types.EnvValue, filepath string, wrapSpecialChars bool
 			builder.WriteString("\n")
 		}
 
-		val := ev.Val
-		if wrapSpecialChars && needsQuoting(val) {
-			val = "'" + val + "'"
+		for _, comment := range ev.Comments {
+			builder.WriteString(comment + "\n")
 		}
 
-		builder.WriteString(fmt.Sprintf("%s=%s\n", ev.Key, val))
+		val := FormatValue(ev.Val, wrapSpecialChars)
+		if ev.Literal {
+			val = formatLiteral(ev.Val, wrapSpecialChars)
+		}
+		builder.WriteString(fmt.Sprintf("%s=%s", ev.Key, val))
+		if ev.InlineComment != "" {
+			builder.WriteString(" " + ev.InlineComment)
+		}
+		builder.WriteString("\n")
+
+		for _, comment := range ev.Trailing {
+			builder.WriteString(comment + "\n")
+		}
 	}
 
 	content := builder.String()
@@ -118,9 +201,50 @@ func WriteEnv(envValues []types.EnvValue, filepath string, wrapSpecialChars bool
 		content = content[:len(content)-1]
 	}
 
-	return os.WriteFile(filepath, []byte(content), 0644)
+	return content
 }
 
+// FormatValue renders a value so that ParseEnv reads it back unchanged.
+// With wrapSpecialChars, shell-special values are single quoted as well.
+func FormatValue(val string, wrapSpecialChars bool) string {
+	if !mustQuote(val) && !(wrapSpecialChars && needsQuoting(val)) {
+		return val
+	}
+
+	// single quotes would stop a ${VAR} in val from expanding
+	if !strings.ContainsAny(val, "'\n\r") && !strings.Contains(val, "${") {
+		return "'" + val + "'"
+	}
+
+	return doubleQuote(val)
+}
+
+// doubleQuote escapes val for double quotes. ${ is left as is, ParseEnv reads
+// \${ back as an escaped, literal ${.
+func doubleQuote(val string) string {
+	return `"` + strings.ReplaceAll(doubleQuoteEscaper.Replace(val), `\${`, `${`) + `"`
+}
+
+// formatLiteral renders a value whose ${ must not be interpolated, single
+// quoted where possible and with every ${ escaped otherwise.
+func formatLiteral(val string, wrapSpecialChars bool) string {
+	if !strings.Contains(val, "${") {
+		return FormatValue(val, wrapSpecialChars)
+	}
+	if !strings.ContainsAny(val, "'\n\r") {
+		return "'" + val + "'"
+	}
+	return doubleQuote(strings.ReplaceAll(val, "${", "$${"))
+}
+
+var doubleQuoteEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	`$`, `\$`,
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
 // needsQuoting returns true if the value contains special characters that should be quoted
 func needsQuoting(val string) bool {
 	// Characters that have special meaning in shell and should be quoted
@@ -128,6 +252,20 @@ func needsQuoting(val string) bool {
 	return strings.ContainsAny(val, specialChars)
 }
 
+// mustQuote returns true if the value would not survive ParseEnv unquoted
+func mustQuote(val string) bool {
+	if val == "" {
+		return false
+	}
+	if val != strings.TrimSpace(val) || strings.ContainsAny(val, "\n\r") {
+		return true
+	}
+	if val[0] == '"' || val[0] == '\'' {
+		return true
+	}
+	return strings.Contains(val, " #") || strings.Contains(val, "\t#")
+}
+
 func DeleteEnvFiles(files []string) error {
 
 	for _, file := range files {
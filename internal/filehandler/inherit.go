@@ -0,0 +1,75 @@
+package filehandler
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+// BaseEnv is the env every other env extends unless configured otherwise.
+const BaseEnv = "common"
+
+// EnvConfigFor returns the config of env. An exact name wins over globs, and
+// longer globs over shorter ones.
+func EnvConfigFor(cfg types.ProjectConfig, env string) (types.EnvConfig, bool) {
+	if envCfg, ok := cfg.Envs[env]; ok {
+		return envCfg, true
+	}
+
+	patterns := make([]string, 0, len(cfg.Envs))
+	for pattern := range cfg.Envs {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, env); err == nil && matched {
+			return cfg.Envs[pattern], true
+		}
+	}
+
+	return types.EnvConfig{}, false
+}
+
+// EnvChain lists env followed by the envs it inherits from, nearest first.
+func EnvChain(cfg types.ProjectConfig, env string) ([]string, error) {
+	chain := []string{env}
+	seen := map[string]bool{env: true}
+
+	for current := env; ; {
+		parent := parentEnv(cfg, current)
+		if parent == "" {
+			return chain, nil
+		}
+
+		chain = append(chain, parent)
+		if seen[parent] {
+			return nil, fmt.Errorf("inheritance cycle: %s", strings.Join(chain, " → "))
+		}
+		seen[parent] = true
+		current = parent
+	}
+}
+
+func parentEnv(cfg types.ProjectConfig, env string) string {
+	envCfg, _ := EnvConfigFor(cfg, env)
+	if envCfg.Extends != nil {
+		// a glob like * also matches the env it points to
+		if *envCfg.Extends == env {
+			return ""
+		}
+		return *envCfg.Extends
+	}
+	if env == BaseEnv {
+		return ""
+	}
+	return BaseEnv
+}
@@ -0,0 +1,73 @@
+package filehandler
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+// SetLease records a temporary swap of the project, nil clears it.
+func SetLease(projectName string, lease *types.EnvLease) error {
+	return updateProject(projectName, func(dir *types.ProjectDir) error {
+		dir.Lease = lease
+		return nil
+	})
+}
+
+// ExpiredLeases returns the projects whose temporary swap ran out by now.
+func ExpiredLeases(now time.Time) ([]types.ProjectDir, error) {
+	dirs, err := ReadProjectDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []types.ProjectDir
+	for _, dir := range dirs {
+		if dir.Lease != nil && !now.Before(LeaseDeadline(dir.Lease)) {
+			expired = append(expired, dir)
+		}
+	}
+	return expired, nil
+}
+
+// LockLease runs fn holding the project's lease lock, with the project as
+// the map has it once the lock is held. Reverts take it, so the watcher and
+// another swapenv command never revert the same lease twice.
+func LockLease(projectName string, fn func(dir *types.ProjectDir) error) error {
+	homeDir, err := GetHomeDirectory(projectName)
+	if err != nil {
+		return err
+	}
+
+	return withFileLock(filepath.Join(homeDir, "lease"), func() error {
+		dir, err := FindProjectByName(projectName)
+		if err != nil {
+			return err
+		}
+		if dir == nil {
+			return fmt.Errorf("project not found: %s", projectName)
+		}
+		return fn(dir)
+	})
+}
+
+func LeaseDeadline(lease *types.EnvLease) time.Time {
+	return time.Unix(lease.ExpiresAt, 0)
+}
+
+// FormatRemaining renders a duration left on a lease, e.g. 29m or 1h5m,
+// down to seconds only in the last minute.
+func FormatRemaining(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	s := d.Round(time.Minute).String()
+	s = strings.TrimSuffix(s, "0s")
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
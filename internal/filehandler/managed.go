@@ -0,0 +1,115 @@
+package filehandler
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A managed block is the part of an env file swapenv owns. It is replaced as
+// a whole on each swap, everything outside the markers is left as written.
+const (
+	managedStartPrefix = "# >>> swapenv "
+	managedStartSuffix = " >>>"
+	managedEnd         = "# <<< swapenv <<<"
+)
+
+func ManagedBlockHeader(env string, version int) string {
+	return fmt.Sprintf("%s%s v%d%s", managedStartPrefix, env, version, managedStartSuffix)
+}
+
+// HasManagedBlock reports whether data holds a managed block.
+func HasManagedBlock(data []byte) bool {
+	_, _, found, err := findManagedBlock(data)
+	return found || err != nil
+}
+
+// ManagedContent returns the part of an env file swapenv manages: the lines
+// inside the managed block if there is one, otherwise the whole file.
+func ManagedContent(data []byte) ([]byte, error) {
+	start, end, found, err := findManagedBlock(data)
+	if err != nil || !found {
+		return data, err
+	}
+
+	block := data[start:end]
+	// drop the marker lines
+	block = block[bytes.IndexByte(block, '\n')+1:]
+	block = block[:bytes.LastIndex(block, []byte(managedEnd))]
+	return block, nil
+}
+
+// ReplaceManagedBlock puts content in the managed block of data under header,
+// appending a new block when data doesn't have one yet.
+func ReplaceManagedBlock(data []byte, header, content string) ([]byte, error) {
+	var block strings.Builder
+	block.WriteString(header + "\n")
+	if content != "" {
+		block.WriteString(strings.TrimSuffix(content, "\n") + "\n")
+	}
+	block.WriteString(managedEnd + "\n")
+
+	start, end, found, err := findManagedBlock(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if found {
+		out.Write(data[:start])
+		out.WriteString(block.String())
+		out.Write(data[end:])
+		return out.Bytes(), nil
+	}
+
+	out.Write(data)
+	if len(data) > 0 {
+		if data[len(data)-1] != '\n' {
+			out.WriteByte('\n')
+		}
+		out.WriteByte('\n')
+	}
+	out.WriteString(block.String())
+	return out.Bytes(), nil
+}
+
+// RemoveManagedBlock drops the managed block from data, along with the blank
+// line ReplaceManagedBlock put before it.
+func RemoveManagedBlock(data []byte) ([]byte, error) {
+	start, end, found, err := findManagedBlock(data)
+	if err != nil || !found {
+		return data, err
+	}
+
+	var out bytes.Buffer
+	out.Write(bytes.TrimSuffix(data[:start], []byte("\n")))
+	out.Write(data[end:])
+	return out.Bytes(), nil
+}
+
+// findManagedBlock returns the byte range of the managed block in data,
+// marker lines included.
+func findManagedBlock(data []byte) (start, end int, found bool, err error) {
+	start = -1
+	for offset := 0; offset < len(data); {
+		next := len(data)
+		if i := bytes.IndexByte(data[offset:], '\n'); i >= 0 {
+			next = offset + i + 1
+		}
+
+		line := strings.TrimSpace(string(data[offset:next]))
+		switch {
+		case start < 0 && strings.HasPrefix(line, managedStartPrefix) && strings.HasSuffix(line, managedStartSuffix):
+			start = offset
+		case start >= 0 && line == managedEnd:
+			return start, next, true, nil
+		}
+
+		offset = next
+	}
+
+	if start >= 0 {
+		return 0, 0, false, fmt.Errorf("swapenv block has no end marker '%s'", managedEnd)
+	}
+	return 0, 0, false, nil
+}
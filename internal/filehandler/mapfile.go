@@ -65,16 +65,22 @@ func ReadProjectDirs() ([]types.ProjectDir, error) {
 		return nil, err
 	}
 
-	if _, err := os.Stat(mapPath); os.IsNotExist(err) {
+	return readProjectDirs(mapPath)
+}
+
+func readProjectDirs(mapPath string) ([]types.ProjectDir, error) {
+	var dirs []types.ProjectDir
+	data, err := readWithBackup(mapPath, func(data []byte) error {
+		return json.Unmarshal(data, &dirs)
+	})
+	if os.IsNotExist(err) {
 		return []types.ProjectDir{}, nil
 	}
-
-	data, err := os.ReadFile(mapPath)
 	if err != nil {
 		return nil, err
 	}
 
-	var dirs []types.ProjectDir
+	dirs = nil
 	if err := json.Unmarshal(data, &dirs); err != nil {
 		return nil, err
 	}
@@ -97,6 +103,28 @@ func FindProjectByLocalPath(localPath string) (*types.ProjectDir, error) {
 	return nil, nil
 }
 
+// FindProjectForPath finds the project mapped to path or to its closest parent
+func FindProjectForPath(path string) (*types.ProjectDir, error) {
+	dirs, err := ReadProjectDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		for _, dir := range dirs {
+			if dir.LocalPath != "" && dir.LocalPath == path {
+				return &dir, nil
+			}
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return nil, nil
+		}
+		path = parent
+	}
+}
+
 func FindProjectByName(projectName string) (*types.ProjectDir, error) {
 	dirs, err := ReadProjectDirs()
 	if err != nil {
@@ -128,54 +156,100 @@ func WriteProjectDirs(dirs []types.ProjectDir) error {
 		return err
 	}
 
+	return withFileLock(mapPath, func() error {
+		return writeProjectDirs(mapPath, dirs)
+	})
+}
+
+func writeProjectDirs(mapPath string, dirs []types.ProjectDir) error {
 	data, err := json.MarshalIndent(dirs, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(mapPath, data, 0644)
+	return WriteFileAtomic(mapPath, data, 0644, true)
 }
 
-func UpsertProjectDir(projectDir types.ProjectDir) error {
-	dirs, err := ReadProjectDirs()
+// updateProjectDirs runs a read-modify-write of map.json while holding its
+// lock, so concurrent swapenv processes don't overwrite each other's changes.
+func updateProjectDirs(update func(dirs []types.ProjectDir) ([]types.ProjectDir, error)) error {
+	mapPath, err := GetMapFilePath()
 	if err != nil {
 		return err
 	}
 
-	found := false
-	for i, dir := range dirs {
-		if dir.LocalPath == projectDir.LocalPath {
-			dirs[i] = projectDir
-			found = true
-			break
+	return withFileLock(mapPath, func() error {
+		dirs, err := readProjectDirs(mapPath)
+		if err != nil {
+			return err
 		}
-	}
 
-	if !found {
-		dirs = append(dirs, projectDir)
-	}
+		dirs, err = update(dirs)
+		if err != nil {
+			return err
+		}
 
-	return WriteProjectDirs(dirs)
+		return writeProjectDirs(mapPath, dirs)
+	})
 }
 
-func UpdateCurrentEnv(projectName, envName string) error {
-	dirs, err := ReadProjectDirs()
-	if err != nil {
-		return err
-	}
+// updateProject applies update to the named project's entry under the map lock.
+func updateProject(projectName string, update func(dir *types.ProjectDir) error) error {
+	return updateProjectDirs(func(dirs []types.ProjectDir) ([]types.ProjectDir, error) {
+		for i := range dirs {
+			if dirs[i].ProjectName == projectName {
+				if dirs[i].VersionNames == nil {
+					dirs[i].VersionNames = make(map[string]string)
+				}
+				return dirs, update(&dirs[i])
+			}
+		}
+		return nil, fmt.Errorf("project not found: %s", projectName)
+	})
+}
 
-	found := false
-	for i, dir := range dirs {
-		if dir.ProjectName == projectName {
-			dirs[i].CurrentEnv = envName
-			found = true
-			break
+func UpsertProjectDir(projectDir types.ProjectDir) error {
+	return updateProjectDirs(func(dirs []types.ProjectDir) ([]types.ProjectDir, error) {
+		for i, dir := range dirs {
+			if dir.LocalPath == projectDir.LocalPath {
+				dirs[i] = projectDir
+				return dirs, nil
+			}
 		}
-	}
 
-	if !found {
-		return fmt.Errorf("project not found in map: %s", projectName)
-	}
+		return append(dirs, projectDir), nil
+	})
+}
+
+// RegisterProjectDir maps localPath to a new project named projectName,
+// prefixing the parent directory when the name is taken. A path that is
+// already mapped, possibly by a concurrent process, is left untouched.
+func RegisterProjectDir(localPath, projectName string) error {
+	return updateProjectDirs(func(dirs []types.ProjectDir) ([]types.ProjectDir, error) {
+		for _, dir := range dirs {
+			if dir.LocalPath == localPath {
+				return dirs, nil
+			}
+		}
 
-	return WriteProjectDirs(dirs)
+		for _, dir := range dirs {
+			if dir.ProjectName == projectName {
+				projectName = filepath.Base(filepath.Dir(localPath)) + "/" + projectName
+				break
+			}
+		}
+
+		return append(dirs, types.ProjectDir{
+			ProjectName:  projectName,
+			LocalPath:    localPath,
+			VersionNames: make(map[string]string),
+		}), nil
+	})
+}
+
+func UpdateCurrentEnv(projectName, envName string) error {
+	return updateProject(projectName, func(dir *types.ProjectDir) error {
+		dir.CurrentEnv = envName
+		return nil
+	})
 }
@@ -0,0 +1,40 @@
+package filehandler
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/reduan2660/swapenv/internal/prompt"
+)
+
+// ConfirmProtected asks for each protected env in envs to be confirmed by
+// typing its name. Envs named in confirmed (--yes-i-mean, comma separated)
+// need no prompt, and without a terminal the others fail.
+func ConfirmProtected(localDirectory string, envs []string, confirmed string) error {
+	cfg, err := ReadProjectConfig(localDirectory)
+	if err != nil {
+		return err
+	}
+
+	confirmedEnvs := strings.Split(confirmed, ",")
+	for _, env := range envs {
+		if envCfg, _ := EnvConfigFor(cfg, env); !envCfg.Protected || slices.Contains(confirmedEnvs, env) {
+			continue
+		}
+
+		typed, err := prompt.Line(fmt.Sprintf("%s is protected, type '%s' to continue: ", env, env))
+		if errors.Is(err, prompt.ErrNotInteractive) {
+			return fmt.Errorf("%s is protected, pass --yes-i-mean %s to confirm", env, env)
+		}
+		if err != nil {
+			return err
+		}
+		if typed != env {
+			return fmt.Errorf("%s is protected and the confirmation didn't match, nothing was done", env)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+package filehandler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+const defaultMaxVersions = 5
+
+var retentionUnits = map[string]time.Duration{
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+	"mo": 30 * 24 * time.Hour,
+	"y":  365 * 24 * time.Hour,
+}
+
+var retentionPattern = regexp.MustCompile(`^(\d+)(h|d|w|mo|y)$`)
+
+// ParseRetention parses a retention window: 7d, 2w, 3mo, 1y, or a Go duration
+// such as 36h30m. An empty window is zero.
+func ParseRetention(window string) (time.Duration, error) {
+	if window == "" {
+		return 0, nil
+	}
+
+	if m := retentionPattern.FindStringSubmatch(window); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return time.Duration(n) * retentionUnits[m[2]], nil
+	}
+
+	d, err := time.ParseDuration(window)
+	if err != nil {
+		return 0, fmt.Errorf("invalid retention window '%s', use e.g. 36h, 7d, 2w, 3mo or 1y", window)
+	}
+	return d, nil
+}
+
+// PruneDecision is the outcome of the retention policy for one version.
+type PruneDecision struct {
+	Version int
+	Created time.Time
+	Keep    bool
+	Reason  string // the first rule that keeps the version
+}
+
+// RetentionPolicyFor returns the policy configured for a project, with
+// keep_last falling back to max_versions.
+func RetentionPolicyFor(dir *types.ProjectDir) (types.RetentionPolicy, error) {
+	cfg, err := ReadProjectConfig(dir.LocalPath)
+	if err != nil {
+		return types.RetentionPolicy{}, err
+	}
+
+	policy := cfg.Retention
+	if policy.KeepLast <= 0 {
+		policy.KeepLast = cfg.MaxVersions
+	}
+	if policy.KeepLast <= 0 {
+		policy.KeepLast = defaultMaxVersions
+	}
+
+	return policy, nil
+}
+
+// PlanPrune applies the project's retention policy to its versions, oldest first.
+func PlanPrune(projectName string, now time.Time) ([]PruneDecision, error) {
+	dir, err := FindProjectByName(projectName)
+	if err != nil {
+		return nil, err
+	}
+	if dir == nil {
+		return nil, fmt.Errorf("project not found: %s", projectName)
+	}
+
+	policy, err := RetentionPolicyFor(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	within, err := ParseRetention(policy.KeepWithin)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := []struct {
+		name   string
+		window string
+		key    func(time.Time) string
+	}{
+		{"daily", policy.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{"weekly", policy.KeepWeekly, func(t time.Time) string { y, w := t.ISOWeek(); return fmt.Sprintf("%d-%d", y, w) }},
+		{"monthly", policy.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	windows := make([]time.Duration, len(buckets))
+	for i, b := range buckets {
+		if windows[i], err = ParseRetention(b.window); err != nil {
+			return nil, err
+		}
+	}
+
+	versions, err := ListVersions(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make([]PruneDecision, len(versions))
+	for i, v := range versions {
+		created, err := versionCreatedAt(projectName, v)
+		if err != nil {
+			return nil, err
+		}
+		decisions[i] = PruneDecision{Version: v, Created: created}
+	}
+
+	keep := func(d *PruneDecision, reason string) {
+		if !d.Keep {
+			d.Keep, d.Reason = true, reason
+		}
+	}
+
+	// walk newest first, so the first version seen in a bucket is its newest
+	seen := make([]map[string]bool, len(buckets))
+	for i := range seen {
+		seen[i] = make(map[string]bool)
+	}
+	for i := len(decisions) - 1; i >= 0; i-- {
+		d := &decisions[i]
+		age := now.Sub(d.Created)
+
+		if d.Version == dir.CurrentVersion {
+			keep(d, "current")
+		}
+		if name, ok := dir.VersionNames[strconv.Itoa(d.Version)]; ok {
+			keep(d, fmt.Sprintf("named '%s'", name))
+		}
+		for _, env := range sortedKeys(dir.EnvVersions) {
+			if dir.EnvVersions[env] == d.Version {
+				keep(d, "pinned by "+env)
+			}
+		}
+		if dir.Lease != nil && dir.Lease.PreviousVersion == d.Version {
+			keep(d, "restored after the temporary swap to "+dir.Lease.Env)
+		}
+		if len(decisions)-i <= policy.KeepLast {
+			keep(d, fmt.Sprintf("last %d", policy.KeepLast))
+		}
+		if within > 0 && age <= within {
+			keep(d, "within "+policy.KeepWithin)
+		}
+		for b, bucket := range buckets {
+			if windows[b] <= 0 || age > windows[b] {
+				continue
+			}
+			key := bucket.key(d.Created.Local())
+			if !seen[b][key] {
+				seen[b][key] = true
+				keep(d, fmt.Sprintf("%s within %s", bucket.name, bucket.window))
+			}
+		}
+	}
+
+	return decisions, nil
+}
+
+// versionCreatedAt reads when a version was created, falling back to the
+// file's modification time for versions written without a timestamp.
+func versionCreatedAt(projectName string, version int) (time.Time, error) {
+	path, err := GetVersionFilePath(projectName, version)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	header, err := ReadProjectHeader(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error reading v%d: %w", version, err)
+	}
+	if header.CreatedAt > 0 {
+		return time.Unix(header.CreatedAt, 0), nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// PruneVersions deletes the versions the retention policy doesn't keep and
+// returns the deleted version numbers.
+func PruneVersions(projectName string) ([]int, error) {
+	decisions, err := PlanPrune(projectName, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []int
+	var errs []error
+	for _, d := range decisions {
+		if d.Keep {
+			continue
+		}
+
+		path, err := GetVersionFilePath(projectName, d.Version)
+		if err == nil {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("v%d: %w", d.Version, err))
+			continue
+		}
+		deleted = append(deleted, d.Version)
+	}
+
+	slices.Sort(deleted)
+	return deleted, errors.Join(errs...)
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
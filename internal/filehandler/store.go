@@ -0,0 +1,275 @@
+package filehandler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/reduan2660/swapenv/internal/crypto"
+	"github.com/reduan2660/swapenv/internal/prompt"
+	"github.com/reduan2660/swapenv/internal/types"
+	"github.com/spf13/viper"
+)
+
+// sealedMagic prefixes every encrypted version file so reads can tell sealed
+// and plaintext files apart, which also lets a store be half migrated.
+const sealedMagic = "swapenv:sealed:v1\n"
+
+const storeCheck = "swapenv"
+
+// the derived key is cached for the salt it was derived with, so a process
+// asks for the passphrase at most once
+var (
+	storeKey     []byte
+	storeKeySalt []byte
+)
+
+// ErrStoreLocked is returned instead of prompting for the passphrase once
+// prompts are disabled.
+var ErrStoreLocked = errors.New("store is encrypted: set SWAPENV_PASSPHRASE or key_file to unlock it")
+
+var promptDisabled bool
+
+// DisablePrompt stops the passphrase prompt for the rest of the process.
+// Commands run from the shell prompt, like hook export and info, must never
+// wait for input.
+func DisablePrompt() {
+	promptDisabled = true
+}
+
+func GetStoreFilePath() (string, error) {
+	homeDir, err := GetBaseDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homeDir, "store.json"), nil
+}
+
+func ReadStoreConfig() (types.StoreConfig, error) {
+	var cfg types.StoreConfig
+
+	storePath, err := GetStoreFilePath()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(storePath)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("error reading store.json: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func WriteStoreConfig(cfg types.StoreConfig) error {
+	storePath, err := GetStoreFilePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(storePath, data, 0600, false)
+}
+
+func RemoveStoreConfig() error {
+	storePath, err := GetStoreFilePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(storePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// StoreKeySource describes where the store secret is taken from.
+func StoreKeySource() string {
+	if keyFile := storeKeyFile(); keyFile != "" {
+		return "key file " + keyFile
+	}
+	if os.Getenv("SWAPENV_PASSPHRASE") != "" {
+		return "passphrase (SWAPENV_PASSPHRASE)"
+	}
+	return "passphrase (prompt)"
+}
+
+func storeKeyFile() string {
+	if keyFile := os.Getenv("SWAPENV_KEY_FILE"); keyFile != "" {
+		return keyFile
+	}
+	return viper.GetString("key_file")
+}
+
+// storeSecret reads the key file, SWAPENV_PASSPHRASE, or prompts for a
+// passphrase, in that order. confirm asks twice when prompting.
+func storeSecret(confirm bool) ([]byte, error) {
+	if keyFile := storeKeyFile(); keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading key file: %w", err)
+		}
+		secret := bytes.TrimSpace(data)
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("key file %s is empty", keyFile)
+		}
+		return secret, nil
+	}
+
+	if passphrase := os.Getenv("SWAPENV_PASSPHRASE"); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	if promptDisabled {
+		return nil, ErrStoreLocked
+	}
+
+	read := prompt.Password
+	if confirm {
+		read = prompt.NewPassword
+	}
+
+	passphrase, err := read("store passphrase: ")
+	if errors.Is(err, prompt.ErrNotInteractive) {
+		return nil, ErrStoreLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == "" {
+		return nil, errors.New("passphrase can't be empty")
+	}
+
+	return []byte(passphrase), nil
+}
+
+// CreateStoreKey derives a key from a new secret and returns the store config
+// that unlocks it. The config is not written.
+func CreateStoreKey() (types.StoreConfig, []byte, error) {
+	secret, err := storeSecret(true)
+	if err != nil {
+		return types.StoreConfig{}, nil, err
+	}
+
+	salt, err := crypto.NewSalt()
+	if err != nil {
+		return types.StoreConfig{}, nil, err
+	}
+
+	cfg := types.StoreConfig{
+		Encrypted:  true,
+		KDF:        "pbkdf2-sha256",
+		Iterations: crypto.KDFIterations,
+		Salt:       salt,
+	}
+
+	key, err := crypto.DeriveKey(secret, salt, cfg.Iterations)
+	if err != nil {
+		return cfg, nil, err
+	}
+
+	cfg.Check, err = crypto.Seal([]byte(storeCheck), key)
+	if err != nil {
+		return cfg, nil, err
+	}
+
+	storeKey, storeKeySalt = key, salt
+	return cfg, key, nil
+}
+
+// UnlockStore returns the key for an encrypted store, or nil for a plaintext one.
+func UnlockStore() ([]byte, error) {
+	cfg, err := ReadStoreConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Encrypted {
+		return nil, nil
+	}
+
+	if storeKey != nil && bytes.Equal(storeKeySalt, cfg.Salt) {
+		return storeKey, nil
+	}
+
+	secret, err := storeSecret(false)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := crypto.DeriveKey(secret, cfg.Salt, cfg.Iterations)
+	if err != nil {
+		return nil, err
+	}
+
+	if check, err := crypto.Open(cfg.Check, key); err != nil || string(check) != storeCheck {
+		return nil, errors.New("wrong passphrase or key file for the encrypted store")
+	}
+
+	storeKey, storeKeySalt = key, cfg.Salt
+	return key, nil
+}
+
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(sealedMagic))
+}
+
+func SealWithKey(data, key []byte) ([]byte, error) {
+	sealed, err := crypto.Seal(data, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(sealedMagic), sealed...), nil
+}
+
+func OpenWithKey(data, key []byte) ([]byte, error) {
+	opened, err := crypto.Open(data[len(sealedMagic):], key)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting version file: %w", err)
+	}
+
+	return opened, nil
+}
+
+// ReadVersionFile reads a version file, decrypting it when it is sealed.
+func ReadVersionFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !IsSealed(data) {
+		return data, err
+	}
+
+	key, err := UnlockStore()
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, fmt.Errorf("%s is encrypted but store.json is missing", filepath.Base(path))
+	}
+
+	return OpenWithKey(data, key)
+}
+
+// sealVersion encrypts a version file's content when the store is encrypted.
+func sealVersion(data []byte) ([]byte, error) {
+	key, err := UnlockStore()
+	if err != nil || key == nil {
+		return data, err
+	}
+
+	return SealWithKey(data, key)
+}
@@ -8,7 +8,7 @@ import (
 	"sort"
 	"strconv"
 
-	"github.com/spf13/viper"
+	"github.com/reduan2660/swapenv/internal/types"
 )
 
 func MigrateProjectIfNeeded(projectName string) error {
@@ -40,23 +40,11 @@ func MigrateProjectIfNeeded(projectName string) error {
 }
 
 func UpdateProjectVersion(projectName string, currentVersion, latestVersion int) error {
-	dirs, err := ReadProjectDirs()
-	if err != nil {
-		return err
-	}
-
-	for i, dir := range dirs {
-		if dir.ProjectName == projectName {
-			dirs[i].CurrentVersion = currentVersion
-			dirs[i].LatestVersion = latestVersion
-			if dirs[i].VersionNames == nil {
-				dirs[i].VersionNames = make(map[string]string)
-			}
-			return WriteProjectDirs(dirs)
-		}
-	}
-
-	return fmt.Errorf("project not found: %s", projectName)
+	return updateProject(projectName, func(dir *types.ProjectDir) error {
+		dir.CurrentVersion = currentVersion
+		dir.LatestVersion = latestVersion
+		return nil
+	})
 }
 
 func GetVersionFilePath(projectName string, version int) (string, error) {
@@ -142,101 +130,108 @@ func ResolveVersion(projectName, versionStr string) (int, error) {
 	return 0, fmt.Errorf("version '%s' not found", versionStr)
 }
 
-func BumpVersion(projectName string) (int, error) {
+// StoreVersion writes content as the next version of the project and makes
+// it the current one. The version file is written first, under the map lock,
+// so the map never points at a version that doesn't exist.
+func StoreVersion(projectName string, content []byte) (int, error) {
 	if err := MigrateProjectIfNeeded(projectName); err != nil {
 		return 0, err
 	}
 
-	dirs, err := ReadProjectDirs()
+	// sealing may prompt for the passphrase, not while holding the lock
+	sealed, err := sealVersion(content)
 	if err != nil {
 		return 0, err
 	}
 
-	for i, dir := range dirs {
-		if dir.ProjectName == projectName {
-			if dirs[i].VersionNames == nil {
-				dirs[i].VersionNames = make(map[string]string)
-			}
-
-			dirs[i].LatestVersion++
-			dirs[i].CurrentVersion = dirs[i].LatestVersion
-
-			if err := WriteProjectDirs(dirs); err != nil {
-				return 0, err
-			}
-			return dirs[i].LatestVersion, nil
+	var newVersion int
+	err = updateProject(projectName, func(dir *types.ProjectDir) error {
+		version := dir.LatestVersion + 1
+		versionPath, err := GetVersionFilePath(projectName, version)
+		if err != nil {
+			return err
+		}
+		// version files hold secrets, sealed or not
+		if err := WriteFileAtomic(versionPath, sealed, 0600, false); err != nil {
+			return err
 		}
-	}
-
-	return 0, fmt.Errorf("project not found: %s", projectName)
-}
-
-func PruneVersions(projectName string) error {
-	dir, err := FindProjectByName(projectName)
-	if err != nil || dir == nil {
-		return err
-	}
 
-	versions, err := ListVersions(projectName)
+		dir.LatestVersion = version
+		dir.CurrentVersion = version
+		newVersion = version
+		return nil
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	maxVersions := viper.GetInt("max_versions")
-	if len(versions) <= maxVersions {
-		return nil
-	}
+	return newVersion, nil
+}
 
-	toDelete := versions[:len(versions)-maxVersions]
+func SetCurrentVersion(projectName string, version int) error {
+	return updateProject(projectName, func(dir *types.ProjectDir) error {
+		dir.CurrentVersion = version
+		return nil
+	})
+}
 
-	for _, v := range toDelete {
-		// Skip named (protected) versions
-		if _, isNamed := dir.VersionNames[strconv.Itoa(v)]; isNamed {
-			continue
-		}
-		// Skip current version
-		if v == dir.CurrentVersion {
-			continue
-		}
+func RenameVersion(projectName string, version int, name string) error {
+	return updateProject(projectName, func(dir *types.ProjectDir) error {
+		dir.VersionNames[strconv.Itoa(version)] = name
+		return nil
+	})
+}
 
-		path, _ := GetVersionFilePath(projectName, v)
-		os.Remove(path)
+// EnvVersion returns the version env is read from: its pin, or else the
+// project's current version.
+func EnvVersion(dir *types.ProjectDir, env string) int {
+	if v, ok := dir.EnvVersions[env]; ok {
+		return v
 	}
-
-	return nil
+	return dir.CurrentVersion
 }
 
-func SetCurrentVersion(projectName string, version int) error {
-	dirs, err := ReadProjectDirs()
+// GetEnvVersionFilePath returns the version file env is read from.
+func GetEnvVersionFilePath(projectName, env string) (string, error) {
+	dir, err := FindProjectByName(projectName)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if dir == nil {
+		return "", fmt.Errorf("project not found: %s", projectName)
 	}
 
-	for i, dir := range dirs {
-		if dir.ProjectName == projectName {
-			dirs[i].CurrentVersion = version
-			return WriteProjectDirs(dirs)
-		}
+	version := EnvVersion(dir, env)
+	if version == 0 {
+		version = 1 // first load will create v1
 	}
 
-	return fmt.Errorf("project not found: %s", projectName)
+	return GetVersionFilePath(projectName, version)
 }
 
-func RenameVersion(projectName string, version int, name string) error {
-	dirs, err := ReadProjectDirs()
-	if err != nil {
-		return err
-	}
+// PinEnvVersion makes env read from version. Pinning to the current version
+// removes the pin, so the env follows the project again.
+func PinEnvVersion(projectName, env string, version int) error {
+	return updateProject(projectName, func(dir *types.ProjectDir) error {
+		if version == dir.CurrentVersion {
+			delete(dir.EnvVersions, env)
+			return nil
+		}
 
-	for i, dir := range dirs {
-		if dir.ProjectName == projectName {
-			if dirs[i].VersionNames == nil {
-				dirs[i].VersionNames = make(map[string]string)
-			}
-			dirs[i].VersionNames[strconv.Itoa(version)] = name
-			return WriteProjectDirs(dirs)
+		if dir.EnvVersions == nil {
+			dir.EnvVersions = make(map[string]int)
 		}
-	}
+		dir.EnvVersions[env] = version
+		return nil
+	})
+}
 
-	return fmt.Errorf("project not found: %s", projectName)
+// UnpinEnvs lets envs follow the project's current version again.
+func UnpinEnvs(projectName string, envs []string) error {
+	return updateProject(projectName, func(dir *types.ProjectDir) error {
+		for _, env := range envs {
+			delete(dir.EnvVersions, env)
+		}
+		return nil
+	})
 }
@@ -0,0 +1,68 @@
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+var ErrNotInteractive = errors.New("not running in an interactive terminal")
+
+// IsInteractive reports whether stdin is a terminal a prompt can be read from.
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Line reads a line from the terminal, without the trailing newline.
+func Line(label string) (string, error) {
+	if !IsInteractive() {
+		return "", ErrNotInteractive
+	}
+
+	fmt.Fprint(os.Stderr, label)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Password reads a line from the terminal without echoing it.
+func Password(label string) (string, error) {
+	if !IsInteractive() {
+		return "", ErrNotInteractive
+	}
+
+	fmt.Fprint(os.Stderr, label)
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(secret), nil
+}
+
+// NewPassword asks for a password twice and checks both entries match.
+func NewPassword(label string) (string, error) {
+	secret, err := Password(label)
+	if err != nil {
+		return "", err
+	}
+
+	again, err := Password("confirm " + label)
+	if err != nil {
+		return "", err
+	}
+
+	if secret != again {
+		return "", errors.New("entries do not match")
+	}
+
+	return secret, nil
+}
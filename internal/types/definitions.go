@@ -14,6 +14,27 @@ type ProjectDir struct {
 	CurrentVersion int               `json:"CurrentVersion"`
 	LatestVersion  int               `json:"latestVersion"`
 	VersionNames   map[string]string `json:"versionNames,omitempty"`
+
+	// EnvVersions pins an env to an older version, other envs follow CurrentVersion
+	EnvVersions map[string]int `json:"envVersions,omitempty"`
+
+	// EnvChecksums holds the sha256 of each env file as swapenv last wrote it
+	EnvChecksums map[string]string `json:"envChecksums,omitempty"`
+
+	// EnvKept lists, per env file, the keys the last swap kept from the file
+	// that aren't part of the env it swapped to
+	EnvKept map[string][]string `json:"envKept,omitempty"`
+
+	// Lease is set while an env is active temporarily (swapenv to --for)
+	Lease *EnvLease `json:"lease,omitempty"`
+}
+
+// EnvLease records what to swap back to when a temporary swap runs out.
+type EnvLease struct {
+	Env             string `json:"env"`
+	PreviousEnv     string `json:"previousEnv"`
+	PreviousVersion int    `json:"previousVersion,omitempty"`
+	ExpiresAt       int64  `json:"expiresAt"`
 }
 
 type Project struct {
@@ -23,14 +44,109 @@ type Project struct {
 	LocalDirectory string                `json:"localDirectory"`
 	CreatedAt      int64                 `json:"createdAt"`
 	ModifiedAt     int64                 `json:"modifiedAt"`
+	Operation      string                `json:"operation,omitempty"` // what created the version, one of the Op* constants
+	Source         []string              `json:"source,omitempty"`    // files loaded, or where a receive came from
+	Message        string                `json:"message,omitempty"`
 	Envs           map[string][]EnvValue `json:"-"`
 }
 
+// Operations that create a version
+const (
+	OpLoad    = "load"
+	OpImport  = "import"
+	OpReceive = "receive"
+	OpEdit    = "edit"
+	OpRevert  = "revert"
+	OpPick    = "pick"
+)
+
+// ProjectConfig is read from default.yaml, overridden per project by .swapenv.yaml
+type ProjectConfig struct {
+	// LoadPatterns are globs for `swapenv load`, with {env} capturing the env name
+	LoadPatterns []string `mapstructure:"load_patterns"`
+
+	MaxVersions int             `mapstructure:"max_versions"`
+	Retention   RetentionPolicy `mapstructure:"retention"`
+
+	// ManagedBlock makes `swapenv to` own a marked block of .env instead of
+	// merging into the whole file
+	ManagedBlock bool `mapstructure:"managed_block"`
+
+	// Targets are the files `swapenv to` writes, default .env
+	Targets []EnvTarget `mapstructure:"targets"`
+
+	// Envs configures envs by name or glob, e.g. preview-*
+	Envs map[string]EnvConfig `mapstructure:"envs"`
+
+	// PreSwap and PostSwap are shell commands run around every `swapenv to`
+	PreSwap  []string `mapstructure:"pre_swap"`
+	PostSwap []string `mapstructure:"post_swap"`
+}
+
+type EnvConfig struct {
+	// Extends is the env this one inherits from. Unset means common, an empty
+	// string means nothing.
+	Extends *string `mapstructure:"extends"`
+
+	// PreSwap and PostSwap run after the global hooks when swapping to this env
+	PreSwap  []string `mapstructure:"pre_swap"`
+	PostSwap []string `mapstructure:"post_swap"`
+
+	// Protected envs need the env name typed, or --yes-i-mean <env>, before
+	// they are written out or shared
+	Protected bool `mapstructure:"protected"`
+}
+
+// EnvTarget is a file written by `swapenv to`, relative to the project
+// directory. Prefix keeps only the keys starting with it, Rename writes keys
+// under another name and always includes them.
+type EnvTarget struct {
+	Path        string       `mapstructure:"path"`
+	Prefix      string       `mapstructure:"prefix"`
+	StripPrefix bool         `mapstructure:"strip_prefix"`
+	Rename      []RenameRule `mapstructure:"rename"`
+}
+
+type RenameRule struct {
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+}
+
+// RetentionPolicy decides which versions survive pruning. A version is kept if
+// any rule keeps it. Windows are durations like 36h, 7d, 2w, 3mo or 1y.
+type RetentionPolicy struct {
+	KeepLast    int    `mapstructure:"keep_last"`    // newest N versions, defaults to max_versions
+	KeepWithin  string `mapstructure:"keep_within"`  // every version newer than this
+	KeepDaily   string `mapstructure:"keep_daily"`   // newest version of each day within this window
+	KeepWeekly  string `mapstructure:"keep_weekly"`  // newest version of each week within this window
+	KeepMonthly string `mapstructure:"keep_monthly"` // newest version of each month within this window
+}
+
+// StoreConfig describes how version files in the store are sealed. A missing
+// store.json means the store is plaintext.
+type StoreConfig struct {
+	Encrypted  bool   `json:"encrypted"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Check      []byte `json:"check"` // a known value sealed with the key, to detect a wrong passphrase
+}
+
 type EnvValue struct {
 	Key     string `json:"key"`
 	Val     string `json:"val"`
 	Order   int    `json:"order"`
 	Spacing int    `json:"spacing"`
+
+	// Comments holds the comment block above the key, blank lines inside the
+	// block kept as "" so section headers stay detached from the key.
+	// Spacing counts the blank lines before the block.
+	Comments      []string `json:"comments,omitempty"`
+	InlineComment string   `json:"inlineComment,omitempty"`
+	Trailing      []string `json:"trailing,omitempty"` // comments after the last key of the file
+
+	// Literal marks a single quoted value, kept as written instead of expanding ${VAR}
+	Literal bool `json:"literal,omitempty"`
 }
 
 func (e EnvValue) String() string {
@@ -46,6 +162,16 @@ func (p *Project) MarshalJSON() ([]byte, error) {
 		"modifiedAt":     p.ModifiedAt,
 	}
 
+	if p.Operation != "" {
+		m["operation"] = p.Operation
+	}
+	if len(p.Source) > 0 {
+		m["source"] = p.Source
+	}
+	if p.Message != "" {
+		m["message"] = p.Message
+	}
+
 	for envName, envValues := range p.Envs {
 		m[envName] = envValues
 	}
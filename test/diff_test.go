@@ -0,0 +1,132 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+)
+
+func setupDiffVersions(t *testing.T) {
+	t.Helper()
+
+	createEnvFile(t, ".dev.env", `SAME=1
+CHANGED=old
+REMOVED=gone`)
+	loadAll(t)
+
+	createEnvFile(t, ".dev.env", `SAME=1
+CHANGED=new
+ADDED=fresh`)
+	createEnvFile(t, ".prod.env", `ONLY_PROD=yes`)
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("replace", "true")
+	defer loadCmd.Flags().Set("replace", "false")
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runDiff(t *testing.T, from, to, env string, reveal bool, format string) string {
+	t.Helper()
+
+	diffCmd := cmd.GetVersionDiffCmd()
+	diffCmd.Flags().Set("env", env)
+	diffCmd.Flags().Set("reveal", boolString(reveal))
+	diffCmd.Flags().Set("format", format)
+	defer func() {
+		diffCmd.Flags().Set("env", "")
+		diffCmd.Flags().Set("reveal", "false")
+		diffCmd.Flags().Set("format", "text")
+	}()
+
+	output, err := captureOutput(func() error {
+		return diffCmd.RunE(diffCmd, []string{from, to})
+	})
+	if err != nil {
+		t.Fatalf("version diff failed: %v", err)
+	}
+	return output
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+func TestVersionDiffMasked(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupDiffVersions(t)
+
+	output := runDiff(t, "1", "latest", "", false, "text")
+
+	for _, want := range []string{"+ ADDED=********", "- REMOVED", "~ CHANGED: ******** → ********", "prod (env added)", "+ ONLY_PROD"} {
+		if !contains(output, want) {
+			t.Errorf("diff should contain %q, got:\n%s", want, output)
+		}
+	}
+	if contains(output, "SAME") {
+		t.Errorf("unchanged keys should not be listed, got:\n%s", output)
+	}
+	if contains(output, "fresh") || contains(output, "old") {
+		t.Errorf("values should be masked without --reveal, got:\n%s", output)
+	}
+}
+
+func TestVersionDiffRevealJSON(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupDiffVersions(t)
+
+	output := runDiff(t, "1", "2", "dev", true, "json")
+
+	var result struct {
+		From int `json:"from"`
+		To   int `json:"to"`
+		Envs []struct {
+			Env     string `json:"env"`
+			Changes []struct {
+				Key    string `json:"key"`
+				Change string `json:"change"`
+				Old    string `json:"old"`
+				New    string `json:"new"`
+			} `json:"changes"`
+		} `json:"envs"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("output should be json: %v\n%s", err, output)
+	}
+
+	if result.From != 1 || result.To != 2 || len(result.Envs) != 1 || result.Envs[0].Env != "dev" {
+		t.Fatalf("unexpected diff: %+v", result)
+	}
+
+	changes := map[string]string{}
+	for _, c := range result.Envs[0].Changes {
+		changes[c.Key] = c.Change + ":" + c.Old + ":" + c.New
+	}
+	expected := map[string]string{
+		"CHANGED": "changed:old:new",
+		"ADDED":   "added::fresh",
+		"REMOVED": "removed:gone:",
+	}
+	for key, want := range expected {
+		if changes[key] != want {
+			t.Errorf("%s: expected %q, got %q", key, want, changes[key])
+		}
+	}
+}
+
+func TestVersionDiffNoChanges(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupDiffVersions(t)
+
+	output := runDiff(t, "2", "latest", "", false, "text")
+	if !contains(output, "no differences") {
+		t.Errorf("diffing a version with itself should report no differences, got:\n%s", output)
+	}
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+)
+
+func runDryRun(t *testing.T, env string, replace, reveal bool) string {
+	t.Helper()
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("dry-run", "true")
+	toCmd.Flags().Set("replace", boolString(replace))
+	toCmd.Flags().Set("reveal", boolString(reveal))
+	defer func() {
+		toCmd.Flags().Set("dry-run", "false")
+		toCmd.Flags().Set("replace", "false")
+		toCmd.Flags().Set("reveal", "false")
+	}()
+
+	output, err := captureOutput(func() error {
+		return toCmd.RunE(toCmd, []string{env})
+	})
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	return output
+}
+
+func TestToDryRun(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".dev.env", `A=1
+B=2
+ONLY_DEV=x`)
+	createEnvFile(t, ".prod.env", `A=9
+B=2
+NEW=y`)
+	loadAll(t)
+
+	swapTo(t, "dev")
+	before := readDotEnv(t)
+
+	output := runDryRun(t, "prod", false, false)
+	for _, want := range []string{"dry run: dev → prod (v1), nothing written", "~ A: ******** → ********", "+ NEW=********", "= ONLY_DEV (kept from the current file"} {
+		if !contains(output, want) {
+			t.Errorf("expected %q in dry run, got:\n%s", want, output)
+		}
+	}
+	if contains(output, " B") {
+		t.Errorf("unchanged keys should not be listed, got:\n%s", output)
+	}
+
+	if output := runDryRun(t, "prod", true, true); !contains(output, "~ A: 1 → 9") || !contains(output, "- ONLY_DEV") {
+		t.Errorf("expected revealed changes with ONLY_DEV removed, got:\n%s", output)
+	}
+
+	if readDotEnv(t) != before {
+		t.Error("dry run should not write .env")
+	}
+	if active, _ := filehandler.ReadActiveEnv(testProjectDir); active != "dev" {
+		t.Errorf("dry run should not change the active env, got %q", active)
+	}
+}
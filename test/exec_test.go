@@ -0,0 +1,55 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+	"github.com/reduan2660/swapenv/internal/cmd_exec"
+)
+
+func TestExec(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".common.env", `SHARED=common`)
+	createEnvFile(t, ".staging.env", `API_URL=https://staging.example.com
+GREETING=hello ${SHARED}`)
+
+	loadAll(t)
+
+	execCmd := cmd.GetExecCmd()
+	script := `test "$API_URL" = "https://staging.example.com" && test "$GREETING" = "hello common" && test "$SHARED" = "common"`
+	if err := execCmd.RunE(execCmd, []string{"staging", "sh", "-c", script}); err != nil {
+		t.Fatalf("exec should inject resolved env: %v", err)
+	}
+
+	if _, err := os.Stat(".env"); !os.IsNotExist(err) {
+		t.Error("exec should not write .env")
+	}
+}
+
+func TestExecExitCode(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".dev.env", `ENV_1=dev`)
+
+	loadAll(t)
+
+	execCmd := cmd.GetExecCmd()
+	err := execCmd.RunE(execCmd, []string{"dev", "sh", "-c", "exit 3"})
+
+	var exitErr *cmd_exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected ExitError, got %v", err)
+	}
+	if exitErr.Code != 3 {
+		t.Errorf("expected exit code 3, got %d", exitErr.Code)
+	}
+
+	if err := execCmd.RunE(execCmd, []string{"missing", "true"}); err == nil {
+		t.Error("exec with unknown env should fail")
+	}
+}
@@ -0,0 +1,41 @@
+//go:build unix
+
+package test
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/reduan2660/swapenv/cmd"
+	"github.com/reduan2660/swapenv/internal/cmd_exec"
+)
+
+func TestExecSignals(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".dev.env", `ENV_1=dev`)
+	loadAll(t)
+
+	// ^C reaches the child from the terminal, swapenv must not send it again,
+	// while SIGTERM sent to swapenv is passed on
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+		time.Sleep(100 * time.Millisecond)
+		syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	}()
+
+	execCmd := cmd.GetExecCmd()
+	err := execCmd.RunE(execCmd, []string{"dev", "sh", "-c", `trap "exit 3" INT; trap "exit 7" TERM; sleep 5 & wait`})
+
+	var exitErr *cmd_exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected ExitError, got %v", err)
+	}
+	if exitErr.Code != 7 {
+		t.Errorf("expected the forwarded SIGTERM to exit with 7, got %d", exitErr.Code)
+	}
+}
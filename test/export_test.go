@@ -0,0 +1,135 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+)
+
+func setupExportProject(t *testing.T) {
+	t.Helper()
+
+	createEnvFile(t, ".common.env", `SHARED=common`)
+	createEnvFile(t, ".dev.env", `API_URL=https://dev.example.com
+QUOTE=it's "quoted"
+CERT="line1\nline2"`)
+
+	loadAll(t)
+}
+
+func runExport(t *testing.T, format, output string) (string, error) {
+	t.Helper()
+
+	exportCmd := cmd.GetExportCmd()
+	exportCmd.Flags().Set("env", "dev")
+	exportCmd.Flags().Set("format", format)
+	exportCmd.Flags().Set("output", output)
+
+	return captureOutput(func() error {
+		return exportCmd.RunE(exportCmd, []string{})
+	})
+}
+
+func TestExportJSON(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupExportProject(t)
+
+	output, err := runExport(t, "json", "")
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal([]byte(output), &values); err != nil {
+		t.Fatalf("invalid json output: %v\n%s", err, output)
+	}
+
+	if values["QUOTE"] != `it's "quoted"` {
+		t.Errorf("unexpected QUOTE: %q", values["QUOTE"])
+	}
+	if values["CERT"] != "line1\nline2" {
+		t.Errorf("unexpected CERT: %q", values["CERT"])
+	}
+	if values["SHARED"] != "common" {
+		t.Error("export should include common values")
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupExportProject(t)
+
+	expected := map[string]string{
+		"shell":      `export QUOTE='it'\''s "quoted"'`,
+		"systemd":    `CERT="line1\nline2"`,
+		"github":     "API_URL=https://dev.example.com\n",
+		"yaml":       `"QUOTE": "it's \"quoted\""`,
+		"k8s-secret": "kind: Secret\nmetadata:\n  name: \"test-project-dev\"\ntype: Opaque\nstringData:\n",
+	}
+
+	for format, want := range expected {
+		output, err := runExport(t, format, "")
+		if err != nil {
+			t.Fatalf("export --format %s failed: %v", format, err)
+		}
+		if !contains(output, want) {
+			t.Errorf("%s output should contain %q, got:\n%s", format, want, output)
+		}
+	}
+
+	if _, err := runExport(t, "docker", ""); err == nil {
+		t.Error("docker format should reject multiline values")
+	}
+
+	if _, err := runExport(t, "unknown", ""); err == nil {
+		t.Error("unknown format should return an error")
+	}
+}
+
+func TestExportToFile(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupExportProject(t)
+
+	if _, err := runExport(t, "shell", "exported.sh"); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	defer cmd.GetExportCmd().Flags().Set("output", "")
+
+	content, err := os.ReadFile("exported.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(string(content), "export API_URL='https://dev.example.com'") {
+		t.Errorf("unexpected file content:\n%s", string(content))
+	}
+}
+
+func TestExportRejectsInvalidKeys(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, "dev.json", `{"GOOD": "1", "X;touch pwned": "1"}`)
+	if err := runImport(t, "dev.json", "dev", "json", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{"shell", "docker", "systemd", "github"} {
+		output, err := runExport(t, format, "")
+		if err == nil || !contains(err.Error(), "not a valid variable name") {
+			t.Errorf("%s: expected an invalid key error, got %v", format, err)
+		}
+		if contains(output, "pwned") {
+			t.Errorf("%s: the invalid key should not be written, got:\n%s", format, output)
+		}
+	}
+
+	// formats that quote their keys can hold any key
+	if output, err := runExport(t, "json", ""); err != nil || !contains(output, `"X;touch pwned"`) {
+		t.Errorf("json should quote the key, got %v:\n%s", err, output)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/reduan2660/swapenv/cmd"
 	"github.com/spf13/viper"
 )
 
@@ -57,6 +58,43 @@ func createEnvFile(t *testing.T, filename, content string) {
 	}
 }
 
+// loadAll loads every env file found through the default patterns.
+func loadAll(t *testing.T) {
+	t.Helper()
+
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("env", "*")
+	loadCmd.Flags().Set("replace", "false")
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+}
+
+// swapTo runs swapenv to env, merging into the current .env.
+func swapTo(t *testing.T, env string) {
+	t.Helper()
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("replace", "false")
+	toCmd.Flags().Set("version", "")
+	if err := toCmd.RunE(toCmd, []string{env}); err != nil {
+		t.Fatalf("to %s failed: %v", env, err)
+	}
+}
+
+// replaceTo runs swapenv to env --replace, overwriting the current .env.
+func replaceTo(t *testing.T, env string) {
+	t.Helper()
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("replace", "true")
+	toCmd.Flags().Set("version", "")
+	defer toCmd.Flags().Set("replace", "false")
+	if err := toCmd.RunE(toCmd, []string{env}); err != nil {
+		t.Fatalf("to %s --replace failed: %v", env, err)
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) &&
 		(s == substr ||
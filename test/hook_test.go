@@ -0,0 +1,142 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+)
+
+var exportedVar = regexp.MustCompile(`export (SWAPENV_STAMP|SWAPENV_PREV)='([^']*)';`)
+
+// applyHookOutput mimics the shell eval of hook state variables
+func applyHookOutput(t *testing.T, output string) {
+	t.Helper()
+	t.Setenv("SWAPENV_PREV", "")
+	for _, match := range exportedVar.FindAllStringSubmatch(output, -1) {
+		t.Setenv(match[1], match[2])
+	}
+}
+
+func runHookExport(t *testing.T) string {
+	t.Helper()
+
+	exportCmd := cmd.GetHookExportCmd()
+	output, err := captureOutput(func() error {
+		return exportCmd.RunE(exportCmd, []string{"bash"})
+	})
+	if err != nil {
+		t.Fatalf("hook export failed: %v", err)
+	}
+	return output
+}
+
+func TestHookScript(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	hookCmd := cmd.GetHookCmd()
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		output, err := captureOutput(func() error {
+			return hookCmd.RunE(hookCmd, []string{shell})
+		})
+		if err != nil {
+			t.Fatalf("hook %s failed: %v", shell, err)
+		}
+		if !contains(output, "hook export "+shell) {
+			t.Errorf("%s hook should call hook export, got:\n%s", shell, output)
+		}
+	}
+}
+
+func TestHookExport(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("SWAPENV_STAMP", "")
+	t.Setenv("SWAPENV_PREV", "")
+	t.Setenv("ENV_1", "original")
+
+	createEnvFile(t, ".dev.env", `ENV_1=dev
+ENV_2=it's dev`)
+
+	loadAll(t)
+
+	replaceTo(t, "dev")
+
+	// not allowed yet: nothing exported
+	output := runHookExport(t)
+	if contains(output, "ENV_1") {
+		t.Errorf("env should not be exported before allow, got:\n%s", output)
+	}
+	applyHookOutput(t, output)
+
+	allowCmd := cmd.GetHookAllowCmd()
+	if err := allowCmd.RunE(allowCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// allowed, from a subdirectory
+	subDir := filepath.Join(testProjectDir, "sub")
+	os.MkdirAll(subDir, 0755)
+	os.Chdir(subDir)
+
+	output = runHookExport(t)
+	if !contains(output, "export ENV_1='dev';") || !contains(output, `export ENV_2='it'\''s dev';`) {
+		t.Fatalf("env should be exported after allow, got:\n%s", output)
+	}
+	applyHookOutput(t, output)
+	t.Setenv("ENV_1", "dev")
+
+	// fast path: nothing changed
+	if output := runHookExport(t); output != "" {
+		t.Errorf("unchanged prompt should print nothing, got:\n%s", output)
+	}
+
+	// leaving the project restores the previous values
+	os.Chdir(filepath.Dir(testProjectDir))
+	output = runHookExport(t)
+	if !contains(output, "export ENV_1='original';") || !contains(output, "unset ENV_2;") {
+		t.Errorf("leaving should restore previous values, got:\n%s", output)
+	}
+	if !contains(output, "unset SWAPENV_PREV;") {
+		t.Errorf("leaving should clear hook state, got:\n%s", output)
+	}
+}
+
+func TestHookExportFollowsConfig(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("SWAPENV_STAMP", "")
+	t.Setenv("SWAPENV_PREV", "")
+
+	createEnvFile(t, ".common.env", `SHARED=common`)
+	createEnvFile(t, ".dev.env", `ENV_1=dev`)
+
+	loadAll(t)
+
+	swapTo(t, "dev")
+	allowCmd := cmd.GetHookAllowCmd()
+	if err := allowCmd.RunE(allowCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	output := runHookExport(t)
+	if !contains(output, "export SHARED='common';") {
+		t.Fatalf("dev should inherit common, got:\n%s", output)
+	}
+	applyHookOutput(t, output)
+
+	// editing inheritance changes the export without touching the map
+	createEnvFile(t, ".swapenv.yaml", `envs:
+  dev:
+    extends: ""
+`)
+	output = runHookExport(t)
+	if !contains(output, "unset SHARED;") {
+		t.Errorf("SHARED should be unloaded once dev stops extending common, got:\n%s", output)
+	}
+}
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+)
+
+func setupHooks(t *testing.T, config string) {
+	t.Helper()
+
+	createEnvFile(t, ".swapenv.yaml", config)
+	createEnvFile(t, ".dev.env", `A=dev`)
+	createEnvFile(t, ".prod.env", `A=prod`)
+
+	loadAll(t)
+
+	approveCmd := cmd.GetHooksApproveCmd()
+	if err := approveCmd.RunE(approveCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSwapHooks(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupHooks(t, `pre_swap:
+  - echo "pre $SWAPENV_PROJECT $SWAPENV_FROM_ENV>$SWAPENV_TO_ENV v$SWAPENV_VERSION" >> hooks.log
+post_swap:
+  - echo "post $(cat .env)" >> hooks.log
+envs:
+  prod:
+    post_swap:
+      - echo "prod only" >> hooks.log
+`)
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("version", "")
+	toCmd.Flags().Set("replace", "true")
+	defer toCmd.Flags().Set("replace", "false")
+	for _, env := range []string{"dev", "prod"} {
+		if err := toCmd.RunE(toCmd, []string{env}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	log, err := os.ReadFile("hooks.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `pre test-project >dev v1
+post A=dev
+pre test-project dev>prod v1
+post A=prod
+prod only
+`
+	if string(log) != want {
+		t.Errorf("unexpected hook log:\n%s\nwant:\n%s", log, want)
+	}
+}
+
+func TestFailingPreSwapHookAborts(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupHooks(t, `envs:
+  prod:
+    pre_swap:
+      - exit 1
+`)
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("version", "")
+	if err := toCmd.RunE(toCmd, []string{"prod"}); err == nil {
+		t.Fatal("a failing pre_swap hook should abort the swap")
+	}
+	if _, err := os.Stat(".env"); !os.IsNotExist(err) {
+		t.Error(".env should not be written when the swap is aborted")
+	}
+
+	toCmd.Flags().Set("no-hooks", "true")
+	defer toCmd.Flags().Set("no-hooks", "false")
+	if err := toCmd.RunE(toCmd, []string{"prod"}); err != nil {
+		t.Errorf("--no-hooks should skip the hooks: %v", err)
+	}
+}
+
+func TestProjectHooksNeedApproval(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupHooks(t, `pre_swap:
+  - echo approved >> hooks.log
+`)
+
+	// the repo changes its hooks after they were approved
+	createEnvFile(t, ".swapenv.yaml", `pre_swap:
+  - echo changed >> hooks.log
+`)
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("version", "")
+	err := toCmd.RunE(toCmd, []string{"dev"})
+	if err == nil || !contains(err.Error(), "swapenv hooks approve") {
+		t.Fatalf("changed hooks should need approval, got %v", err)
+	}
+	if _, err := os.Stat("hooks.log"); !os.IsNotExist(err) {
+		t.Error("unapproved hooks should not run")
+	}
+	if _, err := os.Stat(".env"); !os.IsNotExist(err) {
+		t.Error(".env should not be written when the hooks aren't approved")
+	}
+
+	approveCmd := cmd.GetHooksApproveCmd()
+	if err := approveCmd.RunE(approveCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := toCmd.RunE(toCmd, []string{"dev"}); err != nil {
+		t.Fatalf("to dev after approving failed: %v", err)
+	}
+
+	log, err := os.ReadFile("hooks.log")
+	if err != nil || string(log) != "changed\n" {
+		t.Errorf("expected the approved hook to run, got %q %v", log, err)
+	}
+}
+
+func TestHookApprovalIsSeparateFromAutoExport(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupHooks(t, `pre_swap:
+  - echo ran >> hooks.log
+`)
+
+	// denying auto-export leaves approved hooks alone
+	denyCmd := cmd.GetHookDenyCmd()
+	if err := denyCmd.RunE(denyCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	swapTo(t, "dev")
+
+	// and allowing it doesn't approve hooks
+	revokeCmd := cmd.GetHooksRevokeCmd()
+	if err := revokeCmd.RunE(revokeCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	allowCmd := cmd.GetHookAllowCmd()
+	if err := allowCmd.RunE(allowCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	hooksCmd := cmd.GetHooksCmd()
+	output, err := captureOutput(func() error {
+		return hooksCmd.RunE(hooksCmd, []string{})
+	})
+	if err != nil || !contains(output, "echo ran >> hooks.log") || !contains(output, "not approved") {
+		t.Errorf("expected the hooks listed as not approved, got %v:\n%s", err, output)
+	}
+
+	toCmd := cmd.GetToCmd()
+	if err := toCmd.RunE(toCmd, []string{"prod"}); err == nil || !contains(err.Error(), "swapenv hooks approve") {
+		t.Fatalf("revoked hooks should need approval again, got %v", err)
+	}
+
+	log, err := os.ReadFile("hooks.log")
+	if err != nil || string(log) != "ran\n" {
+		t.Errorf("expected the hook to run once, got %q %v", log, err)
+	}
+}
+
+func TestPreSwapHookWritesTarget(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupHooks(t, `pre_swap:
+  - echo FROM_HOOK=1 >> .env
+`)
+
+	swapTo(t, "dev")
+
+	content := readDotEnv(t)
+	if !contains(content, "FROM_HOOK=1") || !contains(content, "A=dev") {
+		t.Errorf("what pre_swap wrote should be merged with dev, got:\n%s", content)
+	}
+}
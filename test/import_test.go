@@ -0,0 +1,181 @@
+package test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+)
+
+func runImport(t *testing.T, file, as, format, service string) error {
+	t.Helper()
+
+	importCmd := cmd.GetImportCmd()
+	importCmd.Flags().Set("as", as)
+	importCmd.Flags().Set("format", format)
+	importCmd.Flags().Set("service", service)
+	importCmd.Flags().Set("replace", "false")
+	return importCmd.RunE(importCmd, []string{file})
+}
+
+func readStoredEnv(t *testing.T, envName string) map[string]string {
+	t.Helper()
+
+	project, err := filehandler.FindProjectByLocalPath(testProjectDir)
+	if err != nil || project == nil {
+		t.Fatalf("project not found: %v", err)
+	}
+
+	path, err := filehandler.GetVersionFilePath(project.ProjectName, project.CurrentVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envValues, err := filehandler.ReadProjectEnv(path, envName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := make(map[string]string)
+	for _, ev := range envValues {
+		values[ev.Key] = ev.Val
+	}
+	return values
+}
+
+func TestImportJSON(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, "env.json", `{"API_URL": "https://example.com", "PORT": 8080, "DEBUG": true, "EMPTY": null}`)
+
+	if err := runImport(t, "env.json", "staging", "", ""); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	values := readStoredEnv(t, "staging")
+	if values["API_URL"] != "https://example.com" || values["PORT"] != "8080" || values["DEBUG"] != "true" {
+		t.Errorf("unexpected values: %v", values)
+	}
+	if _, ok := values["EMPTY"]; !ok {
+		t.Error("null values should be imported as empty")
+	}
+
+	if _, err := os.Stat("env.json"); err != nil {
+		t.Error("import should keep the source file")
+	}
+
+	createEnvFile(t, "nested.json", `{"DB": {"host": "x"}}`)
+	if err := runImport(t, "nested.json", "staging", "json", ""); err == nil {
+		t.Error("nested values should be rejected")
+	}
+}
+
+func TestImportCompose(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, "docker-compose.yml", `services:
+  api:
+    image: api
+    environment:
+      - DB_HOST=db
+      - PASSTHROUGH
+  worker:
+    environment:
+      QUEUE: jobs
+      RETRIES: 3
+  db:
+    image: postgres
+`)
+
+	if err := runImport(t, "docker-compose.yml", "dev", "", ""); err == nil {
+		t.Error("ambiguous compose file should require --service")
+	}
+
+	if err := runImport(t, "docker-compose.yml", "dev", "compose", "api"); err != nil {
+		t.Fatalf("import api failed: %v", err)
+	}
+	values := readStoredEnv(t, "dev")
+	if values["DB_HOST"] != "db" {
+		t.Errorf("unexpected api values: %v", values)
+	}
+	if _, ok := values["PASSTHROUGH"]; ok {
+		t.Error("pass-through entries should be skipped")
+	}
+
+	if err := runImport(t, "docker-compose.yml", "worker", "", "worker"); err != nil {
+		t.Fatalf("import worker failed: %v", err)
+	}
+	values = readStoredEnv(t, "worker")
+	if values["QUEUE"] != "jobs" || values["RETRIES"] != "3" {
+		t.Errorf("unexpected worker values: %v", values)
+	}
+}
+
+func TestImportK8s(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, "manifest.yaml", `apiVersion: v1
+kind: Service
+metadata:
+  name: ignored
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+data:
+  PASSWORD: c2VjcmV0
+stringData:
+  TOKEN: plain
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  LOG_LEVEL: debug
+`)
+
+	if err := runImport(t, "manifest.yaml", "prod", "", ""); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	values := readStoredEnv(t, "prod")
+	if values["PASSWORD"] != "secret" {
+		t.Errorf("secret data should be base64 decoded, got %q", values["PASSWORD"])
+	}
+	if values["TOKEN"] != "plain" || values["LOG_LEVEL"] != "debug" {
+		t.Errorf("unexpected values: %v", values)
+	}
+}
+
+func TestImportCreatesNewVersion(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".dev.env", `ENV_1=dev
+ENV_2=dev`)
+	loadAll(t)
+
+	createEnvFile(t, "dev.yaml", `ENV_1: imported`)
+	if err := runImport(t, "dev.yaml", "dev", "", ""); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	project, err := filehandler.FindProjectByLocalPath(testProjectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if project.CurrentVersion != 2 {
+		t.Errorf("import should create v2, got v%d", project.CurrentVersion)
+	}
+
+	values := readStoredEnv(t, "dev")
+	if values["ENV_1"] != "imported" || values["ENV_2"] != "dev" {
+		t.Errorf("import should fast forward the previous version, got %v", values)
+	}
+}
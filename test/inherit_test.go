@@ -0,0 +1,105 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+)
+
+func setupInheritance(t *testing.T, config string) {
+	t.Helper()
+
+	createEnvFile(t, ".swapenv.yaml", config)
+	createEnvFile(t, ".common.env", `LOG=info
+REGION=us`)
+	createEnvFile(t, ".prod.env", `DB=prod-db
+LOG=warn`)
+	createEnvFile(t, ".staging.env", `DB=staging-db`)
+	createEnvFile(t, ".preview-42.env", `URL=https://pr-42`)
+
+	loadAll(t)
+}
+
+const inheritanceConfig = `envs:
+  staging:
+    extends: prod
+  preview-*:
+    extends: staging
+`
+
+func TestInheritanceChain(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupInheritance(t, inheritanceConfig)
+
+	replaceTo(t, "preview-42")
+
+	want := "URL=https://pr-42\nDB=staging-db\nLOG=warn\nREGION=us"
+	if got := readDotEnv(t); got != want {
+		t.Errorf("unexpected .env:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupInheritance(t, inheritanceConfig)
+
+	explainCmd := cmd.GetExplainCmd()
+	explainCmd.Flags().Set("reveal", "true")
+	defer explainCmd.Flags().Set("reveal", "false")
+
+	output, err := captureOutput(func() error {
+		return explainCmd.RunE(explainCmd, []string{"staging"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"staging → prod → common", "DB=staging-db  staging, overrides prod", "LOG=warn       prod, overrides common", "REGION=us      common"} {
+		if !contains(output, want) {
+			t.Errorf("expected %q in explain, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestInheritanceCycle(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupInheritance(t, `envs:
+  staging:
+    extends: prod
+  prod:
+    extends: staging
+`)
+
+	toCmd := cmd.GetToCmd()
+	err := toCmd.RunE(toCmd, []string{"staging"})
+	if err == nil || !contains(err.Error(), "inheritance cycle: staging → prod → staging") {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+}
+
+func TestInheritNothing(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupInheritance(t, `envs:
+  prod:
+    extends: ""
+`)
+
+	exportCmd := cmd.GetExportCmd()
+	exportCmd.Flags().Set("env", "prod")
+	exportCmd.Flags().Set("format", "json")
+	defer exportCmd.Flags().Set("env", "")
+
+	output, err := captureOutput(func() error {
+		return exportCmd.RunE(exportCmd, []string{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contains(output, "REGION") {
+		t.Errorf("prod extends nothing and should not get common keys, got:\n%s", output)
+	}
+}
@@ -0,0 +1,233 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/reduan2660/swapenv/cmd"
+	"github.com/reduan2660/swapenv/internal/cmd_setter"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+func setupLease(t *testing.T) {
+	t.Helper()
+
+	// reverts are driven by the tests, no watcher processes
+	t.Setenv(cmd_setter.NoWatcherEnv, "1")
+
+	createEnvFile(t, ".dev.env", "A=dev")
+	createEnvFile(t, ".prod.env", "A=prod\nPROD_ONLY=secret")
+
+	loadAll(t)
+}
+
+func runToFor(t *testing.T, env, d string) {
+	t.Helper()
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("version", "")
+	toCmd.Flags().Set("replace", "false")
+	toCmd.Flags().Set("for", d)
+	defer toCmd.Flags().Set("for", "0s")
+
+	if err := toCmd.RunE(toCmd, []string{env}); err != nil {
+		t.Fatalf("to %s --for %s failed: %v", env, d, err)
+	}
+}
+
+// expireNow moves the lease deadline into the past and runs the watcher.
+func expireNow(t *testing.T) {
+	t.Helper()
+
+	project := readLeaseProject(t)
+	if project.Lease == nil {
+		t.Fatal("expected a lease")
+	}
+	lease := *project.Lease
+	lease.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	if err := filehandler.SetLease("test-project", &lease); err != nil {
+		t.Fatal(err)
+	}
+
+	expireCmd := cmd.GetExpireCmd()
+	expireCmd.Flags().Set("project", "test-project")
+	expireCmd.Flags().Set("at", strconv.FormatInt(lease.ExpiresAt, 10))
+	if err := expireCmd.RunE(expireCmd, []string{}); err != nil {
+		t.Fatalf("expire failed: %v", err)
+	}
+}
+
+func readLeaseProject(t *testing.T) *types.ProjectDir {
+	t.Helper()
+
+	project, err := filehandler.FindProjectByName("test-project")
+	if err != nil || project == nil {
+		t.Fatalf("error reading project: %v", err)
+	}
+	return project
+}
+
+func TestToForRevertsToPreviousEnv(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupLease(t)
+
+	swapTo(t, "dev")
+
+	runToFor(t, "prod", "1h")
+
+	project := readLeaseProject(t)
+	if project.CurrentEnv != "prod" || project.Lease == nil {
+		t.Fatalf("expected prod with a lease, got %s %+v", project.CurrentEnv, project.Lease)
+	}
+	if project.Lease.PreviousEnv != "dev" || project.Lease.PreviousVersion != 1 {
+		t.Errorf("lease should revert to dev v1, got %+v", project.Lease)
+	}
+
+	infoCmd := cmd.GetInfoCmd()
+	infoCmd.Flags().Set("format", "plain")
+	infoCmd.Flags().Set("env-only", "false")
+	defer infoCmd.Flags().Set("format", "json")
+	output, err := captureOutput(func() error {
+		return infoCmd.RunE(infoCmd, []string{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(output, "test-project:prod (1h left)") {
+		t.Errorf("info should show the remaining time, got %q", output)
+	}
+
+	// swapping again keeps reverting to the env from before the first temporary swap
+	runToFor(t, "prod", "30m")
+	if lease := readLeaseProject(t).Lease; lease == nil || lease.PreviousEnv != "dev" {
+		t.Errorf("expected the lease to still revert to dev, got %+v", lease)
+	}
+
+	expireNow(t)
+
+	project = readLeaseProject(t)
+	if project.CurrentEnv != "dev" || project.Lease != nil {
+		t.Errorf("expected dev without a lease after expiry, got %s %+v", project.CurrentEnv, project.Lease)
+	}
+
+	content := readDotEnv(t)
+	if !contains(content, "A=dev") {
+		t.Errorf("expected A=dev after revert, got:\n%s", content)
+	}
+	if contains(content, "PROD_ONLY") {
+		t.Errorf("prod keys should not linger after revert, got:\n%s", content)
+	}
+}
+
+func TestToForWithoutActiveEnv(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupLease(t)
+
+	createEnvFile(t, ".env", "LOCAL=mine\n")
+	runToFor(t, "prod", "30m")
+
+	if lease := readLeaseProject(t).Lease; lease == nil || lease.PreviousEnv != "" {
+		t.Fatalf("expected a lease reverting to no env, got %+v", lease)
+	}
+
+	expireNow(t)
+
+	project := readLeaseProject(t)
+	if project.CurrentEnv != "" || project.Lease != nil {
+		t.Errorf("expected no active env after expiry, got %s %+v", project.CurrentEnv, project.Lease)
+	}
+
+	content := readDotEnv(t)
+	if contains(content, "A=") || contains(content, "PROD_ONLY") {
+		t.Errorf("prod keys should be removed after expiry, got:\n%s", content)
+	}
+	if !contains(content, "LOCAL=mine") {
+		t.Errorf("keys not from prod should be kept, got:\n%s", content)
+	}
+}
+
+func TestExpireSkipsReplacedLease(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupLease(t)
+
+	runToFor(t, "prod", "1h")
+	lease := readLeaseProject(t).Lease
+
+	// a watcher of an earlier lease wakes up, the current one isn't due yet
+	expireCmd := cmd.GetExpireCmd()
+	expireCmd.Flags().Set("project", "test-project")
+	expireCmd.Flags().Set("at", strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10))
+	if err := expireCmd.RunE(expireCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd_setter.RevertExpired(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	project := readLeaseProject(t)
+	if project.CurrentEnv != "prod" || project.Lease == nil || project.Lease.ExpiresAt != lease.ExpiresAt {
+		t.Errorf("lease should be left alone, got %s %+v", project.CurrentEnv, project.Lease)
+	}
+	if _, err := os.Stat(".env"); err != nil {
+		t.Error(".env should still be there")
+	}
+}
+
+func TestLeaseKeepsPreviousVersion(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".swapenv.yaml", "max_versions: 1\n")
+	setupLease(t)
+
+	swapTo(t, "dev")
+	runToFor(t, "prod", "1h")
+
+	// a load during the temporary swap would otherwise prune v1
+	createEnvFile(t, ".dev.env", "A=dev2")
+	loadAll(t)
+	if _, err := os.Stat(filepath.Join(testHomeDir, "test-project", "v1.json")); err != nil {
+		t.Fatalf("v1 should be kept for the revert: %v", err)
+	}
+
+	expireNow(t)
+
+	if content := readDotEnv(t); !contains(content, "A=dev") || contains(content, "A=dev2") {
+		t.Errorf("expected dev as of v1 after revert, got:\n%s", content)
+	}
+}
+
+func TestLeaseRevertFailsWithoutPreviousVersion(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupLease(t)
+
+	swapTo(t, "dev")
+	runToFor(t, "prod", "1h")
+
+	// the version is gone, e.g. deleted by hand
+	lease := *readLeaseProject(t).Lease
+	lease.PreviousVersion = 7
+	lease.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	if err := filehandler.SetLease("test-project", &lease); err != nil {
+		t.Fatal(err)
+	}
+
+	err := cmd_setter.RevertExpired(time.Now())
+	if err == nil || !contains(err.Error(), "can't restore dev v7") {
+		t.Fatalf("expected an error about the missing version, got %v", err)
+	}
+	if project := readLeaseProject(t); project.CurrentEnv != "prod" || project.Lease == nil {
+		t.Errorf("the temporary swap should stay until it can be reverted, got %s %+v", project.CurrentEnv, project.Lease)
+	}
+	if content := readDotEnv(t); !contains(content, "A=prod") {
+		t.Errorf(".env should be left alone when the revert fails, got:\n%s", content)
+	}
+}
@@ -189,3 +189,153 @@ ENV_2=dev`
 		t.Error("ENV_2=dev should be merged from previous version")
 	}
 }
+
+func TestLoadCustomPatterns(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".swapenv.yaml", `load_patterns:
+  - .env.{env}
+  - env/{env}.env
+  - config/.{env}.env
+`)
+
+	os.MkdirAll("env", 0755)
+	os.MkdirAll("config", 0755)
+	createEnvFile(t, ".env.dev", `ENV_1=dev`)
+	createEnvFile(t, "env/prod.env", `ENV_1=prod`)
+	createEnvFile(t, "config/.eu.staging.env", `ENV_1=staging`)
+
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("env", "*")
+	loadCmd.Flags().Set("replace", "false")
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	for env, want := range map[string]string{"dev": "dev", "prod": "prod", "eu.staging": "staging"} {
+		values := readStoredEnv(t, env)
+		if values["ENV_1"] != want {
+			t.Errorf("%s: expected ENV_1=%s, got %v", env, want, values)
+		}
+	}
+
+	if _, err := os.Stat("env/prod.env"); !os.IsNotExist(err) {
+		t.Error("env/prod.env should be deleted after load")
+	}
+
+	// spit writes back through the first pattern
+	spitCmd := cmd.GetSpitCmd()
+	spitCmd.Flags().Set("env", "dev")
+	spitCmd.Flags().Set("version", "")
+	if err := spitCmd.RunE(spitCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(".env.dev"); err != nil {
+		t.Error(".env.dev should exist after spit")
+	}
+}
+
+func TestLoadPatternsStayInProject(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	outside := filepath.Join(filepath.Dir(testProjectDir), "secrets")
+	os.MkdirAll(outside, 0755)
+	createEnvFile(t, filepath.Join(outside, ".prod.env"), `ENV_1=prod`)
+
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("env", "*")
+	loadCmd.Flags().Set("replace", "false")
+
+	for _, pattern := range []string{"../secrets/.{env}.env", filepath.ToSlash(outside) + "/.{env}.env"} {
+		createEnvFile(t, ".swapenv.yaml", "load_patterns:\n  - "+pattern+"\n")
+		if err := loadCmd.RunE(loadCmd, []string{}); err == nil {
+			t.Errorf("pattern %s reaches outside the project and should be rejected", pattern)
+		}
+	}
+
+	// a symlinked directory doesn't get around it either
+	if err := os.Symlink(outside, "linked"); err != nil {
+		t.Skip("symlinks not supported")
+	}
+	createEnvFile(t, ".swapenv.yaml", "load_patterns:\n  - linked/.{env}.env\n")
+	if err := loadCmd.RunE(loadCmd, []string{}); err == nil {
+		t.Error("files behind a symlink outside the project should be rejected")
+	}
+
+	if _, err := os.Stat(filepath.Join(outside, ".prod.env")); err != nil {
+		t.Error("files outside the project must not be deleted")
+	}
+}
+
+func TestLoadExplicitPath(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	os.MkdirAll("deploy", 0755)
+	createEnvFile(t, "deploy/prod.env", `ENV_1=prod`)
+
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("env", "*")
+	loadCmd.Flags().Set("replace", "false")
+
+	if err := loadCmd.RunE(loadCmd, []string{"./deploy/prod.env"}); err == nil {
+		t.Error("load of an unmatched file without --as should fail")
+	}
+
+	loadCmd.Flags().Set("as", "Prod")
+	defer loadCmd.Flags().Set("as", "")
+	if err := loadCmd.RunE(loadCmd, []string{"./deploy/prod.env"}); err != nil {
+		t.Fatalf("load --as failed: %v", err)
+	}
+
+	values := readStoredEnv(t, "prod")
+	if values["ENV_1"] != "prod" {
+		t.Errorf("expected ENV_1=prod, got %v", values)
+	}
+
+	if _, err := os.Stat("deploy/prod.env"); err != nil {
+		t.Error("explicitly named files should be kept without --keep")
+	}
+}
+
+func TestLoadReplaceKeepsOtherEnvs(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".common.env", `SHARED=1`)
+	createEnvFile(t, ".dev.env", "ENV_1=dev\nENV_2=dev")
+	createEnvFile(t, ".prod.env", `ENV_1=prod`)
+
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("env", "*")
+	loadCmd.Flags().Set("replace", "false")
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	createEnvFile(t, ".dev.env", `ENV_1=dev_updated`)
+	loadCmd.Flags().Set("env", "dev")
+	loadCmd.Flags().Set("replace", "true")
+	defer loadCmd.Flags().Set("env", "*")
+	defer loadCmd.Flags().Set("replace", "false")
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatalf("load --replace failed: %v", err)
+	}
+
+	dev := readStoredEnv(t, "dev")
+	if dev["ENV_1"] != "dev_updated" {
+		t.Errorf("expected ENV_1=dev_updated, got %v", dev)
+	}
+	if _, ok := dev["ENV_2"]; ok {
+		t.Errorf("--replace should not merge dev with its previous values, got %v", dev)
+	}
+
+	if prod := readStoredEnv(t, "prod"); prod["ENV_1"] != "prod" {
+		t.Errorf("prod should be carried forward, got %v", prod)
+	}
+	if common := readStoredEnv(t, "common"); common["SHARED"] != "1" {
+		t.Errorf("common should be carried forward, got %v", common)
+	}
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+func TestVersionMetadata(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".dev.env", `KEY=1`)
+	createEnvFile(t, ".prod.env", `KEY=2`)
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("env", "*")
+	loadCmd.Flags().Set("replace", "false")
+	loadCmd.Flags().Set("message", "initial secrets")
+	defer loadCmd.Flags().Set("message", "")
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	header, err := filehandler.ReadProjectHeader(filepath.Join(testHomeDir, "test-project", "v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	currentUser, err := user.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Operation != types.OpLoad {
+		t.Errorf("expected operation load, got %q", header.Operation)
+	}
+	if len(header.Source) != 2 || header.Source[0] != ".dev.env" || header.Source[1] != ".prod.env" {
+		t.Errorf("expected sources [.dev.env .prod.env], got %v", header.Source)
+	}
+	if header.Message != "initial secrets" || header.Owner != currentUser.Username || header.CreatedAt == 0 {
+		t.Errorf("unexpected header: %+v", header)
+	}
+
+	// metadata fields are not envs
+	envs, err := filehandler.ListProjectEnv(filepath.Join(testHomeDir, "test-project", "v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(envs) != 2 {
+		t.Errorf("expected 2 envs, got %v", envs)
+	}
+}
+
+func TestVersionLog(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".dev.env", `KEY=1`)
+	loadAll(t)
+
+	createEnvFile(t, "prod.json", `{"KEY": "2"}`)
+	importCmd := cmd.GetImportCmd()
+	importCmd.Flags().Set("message", "from the vault dump")
+	defer importCmd.Flags().Set("message", "")
+	if err := runImport(t, "prod.json", "prod", "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	logCmd := cmd.GetVersionLogCmd()
+	output, err := captureOutput(func() error {
+		return logCmd.RunE(logCmd, []string{})
+	})
+	if err != nil {
+		t.Fatalf("version log failed: %v", err)
+	}
+
+	for _, want := range []string{"version 2 [current] [latest]", "Operation: import", "Source:    prod.json", "from the vault dump", "version 1", "Operation: load", "Envs:      dev\n"} {
+		if !contains(output, want) {
+			t.Errorf("log should contain %q, got:\n%s", want, output)
+		}
+	}
+	if idx2, idx1 := strings.Index(output, "version 2"), strings.Index(output, "version 1"); idx2 > idx1 {
+		t.Error("log should list the newest version first")
+	}
+
+	logCmd.Flags().Set("oneline", "true")
+	defer logCmd.Flags().Set("oneline", "false")
+	output, err = captureOutput(func() error {
+		return logCmd.RunE(logCmd, []string{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(output, "* v2") || !contains(output, "import  from the vault dump") {
+		t.Errorf("unexpected oneline output:\n%s", output)
+	}
+}
+
+func TestLoadRejectsReservedEnvName(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".source.env", `KEY=1`)
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("env", "*")
+	loadCmd.Flags().Set("replace", "false")
+	if err := loadCmd.RunE(loadCmd, []string{}); err == nil {
+		t.Error("loading an env named like a metadata field should fail")
+	}
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+)
+
+func setupManaged(t *testing.T) {
+	t.Helper()
+
+	createEnvFile(t, ".dev.env", `API=dev`)
+	createEnvFile(t, ".prod.env", `API=prod
+PROD_SECRET=s3cret`)
+	loadAll(t)
+
+	createEnvFile(t, ".env", `# my local overrides
+LOCAL=mine
+`)
+}
+
+func readDotEnv(t *testing.T) string {
+	t.Helper()
+
+	content, err := os.ReadFile(".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestToManagedBlock(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupManaged(t)
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("version", "")
+	toCmd.Flags().Set("managed", "true")
+	defer toCmd.Flags().Set("managed", "false")
+	if err := toCmd.RunE(toCmd, []string{"prod"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# my local overrides
+LOCAL=mine
+
+# >>> swapenv prod v1 >>>
+API=prod
+PROD_SECRET=s3cret
+# <<< swapenv <<<
+`
+	if got := readDotEnv(t); got != want {
+		t.Errorf("unexpected .env:\n%s\nwant:\n%s", got, want)
+	}
+
+	// edits outside the block are the user's, not drift
+	createEnvFile(t, ".env", "LOCAL=changed\n"+want[len("# my local overrides\nLOCAL=mine\n"):])
+
+	// the block is kept without --managed
+	toCmd.Flags().Set("managed", "false")
+	if err := toCmd.RunE(toCmd, []string{"dev"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want = `LOCAL=changed
+
+# >>> swapenv dev v1 >>>
+API=dev
+# <<< swapenv <<<
+`
+	if got := readDotEnv(t); got != want {
+		t.Errorf("unexpected .env:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestToManagedBlockDrift(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupManaged(t)
+	createEnvFile(t, ".swapenv.yaml", `managed_block: true`)
+
+	swapTo(t, "dev")
+	if !contains(readDotEnv(t), "# >>> swapenv dev v1 >>>") {
+		t.Fatal("managed_block config should write a managed block")
+	}
+
+	createEnvFile(t, ".env", `LOCAL=mine
+
+# >>> swapenv dev v1 >>>
+API=edited
+# <<< swapenv <<<
+`)
+	toCmd := cmd.GetToCmd()
+	if err := toCmd.RunE(toCmd, []string{"prod"}); err == nil {
+		t.Error("edits inside the block should count as drift")
+	}
+
+	if output := runStatus(t, true); !contains(output, "~ API: dev → edited") || contains(output, "LOCAL") {
+		t.Errorf("status should only compare the block, got:\n%s", output)
+	}
+}
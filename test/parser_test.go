@@ -0,0 +1,136 @@
+package test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/reduan2660/swapenv/internal/cmd_loader"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+func TestParseEnvGrammar(t *testing.T) {
+	content := `# leading comment
+export EXPORTED=yes
+PLAIN = value with spaces   # trailing comment
+COLOR=#fff
+DOUBLE="line1\nline2 \"quoted\" \$HOME"
+SINGLE='literal \n $HOME'
+ENDS_WITH_QUOTE=it's"
+EMPTY=
+EMPTY_COMMENTED= # set me
+CERT="-----BEGIN CERT-----
+abc
+-----END CERT-----"
+JSON='{"a": 1, "b": [1, 2]}'
+AFTER=ok`
+
+	envValues, err := cmd_loader.ParseEnv([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseEnv failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"EXPORTED":        "yes",
+		"PLAIN":           "value with spaces",
+		"COLOR":           "#fff",
+		"DOUBLE":          "line1\nline2 \"quoted\" $HOME",
+		"SINGLE":          `literal \n $HOME`,
+		"ENDS_WITH_QUOTE": `it's"`,
+		"EMPTY":           "",
+		"EMPTY_COMMENTED": "",
+		"CERT":            "-----BEGIN CERT-----\nabc\n-----END CERT-----",
+		"JSON":            `{"a": 1, "b": [1, 2]}`,
+		"AFTER":           "ok",
+	}
+
+	if len(envValues) != len(expected) {
+		t.Fatalf("expected %d values, got %d: %v", len(expected), len(envValues), envValues)
+	}
+
+	for _, ev := range envValues {
+		want, ok := expected[ev.Key]
+		if !ok {
+			t.Errorf("unexpected key %q", ev.Key)
+			continue
+		}
+		if ev.Val != want {
+			t.Errorf("%s: expected %q, got %q", ev.Key, want, ev.Val)
+		}
+		if ev.Key == "EMPTY_COMMENTED" && ev.InlineComment != "# set me" {
+			t.Errorf("EMPTY_COMMENTED: expected comment %q, got %q", "# set me", ev.InlineComment)
+		}
+	}
+
+	if envValues[len(envValues)-1].Order != len(expected) {
+		t.Errorf("expected AFTER to have order %d, got %d", len(expected), envValues[len(envValues)-1].Order)
+	}
+}
+
+func TestParseEnvUnterminatedQuote(t *testing.T) {
+	if _, err := cmd_loader.ParseEnv([]byte("KEY=\"never closed\nOTHER=1")); err == nil {
+		t.Fatal("unterminated quoted value should return an error")
+	}
+}
+
+func TestParseEnvTextAfterQuote(t *testing.T) {
+	for _, line := range []string{`A='it''s'`, `A="x" y`, `A="x"y # comment`} {
+		if _, err := cmd_loader.ParseEnv([]byte(line)); err == nil {
+			t.Errorf("%s: text after the closing quote should return an error", line)
+		}
+	}
+
+	envValues, err := cmd_loader.ParseEnv([]byte(`A="x"   # comment`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envValues[0].Val != "x" || envValues[0].InlineComment != "# comment" {
+		t.Errorf("expected x with a comment, got %+v", envValues[0])
+	}
+}
+
+func TestWriteEnvRoundTrip(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	values := []types.EnvValue{
+		{Key: "PLAIN", Val: "value", Order: 1},
+		{Key: "SPACES", Val: "  padded  ", Order: 2, Spacing: 1},
+		{Key: "HASH", Val: "a #b", Order: 3},
+		{Key: "QUOTES", Val: `"both' kinds`, Order: 4},
+		{Key: "MULTILINE", Val: "line1\nline2\r\nline3", Order: 5},
+		{Key: "BACKSLASH", Val: `C:\path\n$VAR`, Order: 6},
+		{Key: "EMPTY", Val: "", Order: 7},
+		{Key: "TRAILING_QUOTE", Val: `value"`, Order: 8},
+		{Key: "REFERENCES", Val: `it's ${A} \${B} $${C}`, Order: 9},
+	}
+
+	for _, wrap := range []bool{true, false} {
+		if err := filehandler.WriteEnv(values, ".env", wrap); err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := os.ReadFile(".env")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parsed, err := cmd_loader.ParseEnv(content)
+		if err != nil {
+			t.Fatalf("wrap=%v: ParseEnv failed: %v", wrap, err)
+		}
+
+		if len(parsed) != len(values) {
+			t.Fatalf("wrap=%v: expected %d values, got %d", wrap, len(values), len(parsed))
+		}
+
+		for i, ev := range parsed {
+			if ev.Key != values[i].Key || ev.Val != values[i].Val {
+				t.Errorf("wrap=%v: expected %s=%q, got %s=%q", wrap, values[i].Key, values[i].Val, ev.Key, ev.Val)
+			}
+			if ev.Spacing != values[i].Spacing {
+				t.Errorf("wrap=%v: %s: expected spacing %d, got %d", wrap, ev.Key, values[i].Spacing, ev.Spacing)
+			}
+		}
+	}
+}
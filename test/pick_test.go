@@ -0,0 +1,115 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func runPick(t *testing.T, version, env string, keys []string) error {
+	t.Helper()
+
+	pickCmd := cmd.GetVersionPickCmd()
+	pickCmd.Flags().Set("env", env)
+	setSliceFlag(t, pickCmd, "keys", keys)
+	defer func() {
+		pickCmd.Flags().Set("env", "")
+		setSliceFlag(t, pickCmd, "keys", nil)
+	}()
+
+	return pickCmd.RunE(pickCmd, []string{version})
+}
+
+// setSliceFlag replaces a slice flag's value, Set would append to it
+func setSliceFlag(t *testing.T, c *cobra.Command, name string, values []string) {
+	t.Helper()
+
+	if err := c.Flags().Lookup(name).Value.(pflag.SliceValue).Replace(values); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setupPickVersions(t *testing.T) {
+	t.Helper()
+
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("env", "*")
+	loadCmd.Flags().Set("replace", "false")
+
+	createEnvFile(t, ".dev.env", `A=old
+B=old
+C=1`)
+	createEnvFile(t, ".prod.env", `P=old`)
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	createEnvFile(t, ".dev.env", `A=new
+B=new`)
+	createEnvFile(t, ".prod.env", `P=new
+Q=new`)
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVersionPickKeys(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupPickVersions(t)
+
+	if err := runPick(t, "1", "dev", []string{"A"}); err != nil {
+		t.Fatalf("version pick failed: %v", err)
+	}
+
+	dev := readStoredEnv(t, "dev")
+	if dev["A"] != "old" || dev["B"] != "new" || dev["C"] != "1" {
+		t.Errorf("only A should be restored, got %v", dev)
+	}
+	if prod := readStoredEnv(t, "prod"); prod["P"] != "new" {
+		t.Errorf("prod should be untouched, got %v", prod)
+	}
+
+	header, err := filehandler.ReadProjectHeader(filepath.Join(testHomeDir, "test-project", "v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Operation != "pick" || len(header.Source) != 1 || header.Source[0] != "v1 dev A" {
+		t.Errorf("unexpected pick metadata: %+v", header)
+	}
+}
+
+func TestVersionPickWholeEnv(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupPickVersions(t)
+
+	if err := runPick(t, "1", "prod", nil); err != nil {
+		t.Fatalf("version pick failed: %v", err)
+	}
+
+	prod := readStoredEnv(t, "prod")
+	if prod["P"] != "old" {
+		t.Errorf("prod should be restored from v1, got %v", prod)
+	}
+	if _, exists := prod["Q"]; exists {
+		t.Errorf("picking a whole env should restore it exactly, got %v", prod)
+	}
+	if dev := readStoredEnv(t, "dev"); dev["A"] != "new" {
+		t.Errorf("dev should be untouched, got %v", dev)
+	}
+}
+
+func TestVersionPickMissingKey(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupPickVersions(t)
+
+	if err := runPick(t, "1", "", []string{"A", "MISSING"}); err == nil {
+		t.Error("picking a key that isn't in the version should fail")
+	}
+}
@@ -0,0 +1,222 @@
+package test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+)
+
+func setupProtected(t *testing.T) {
+	t.Helper()
+
+	createEnvFile(t, ".swapenv.yaml", `envs:
+  prod:
+    protected: true
+  staging:
+    protected: true
+`)
+	createEnvFile(t, ".dev.env", `A=dev`)
+	createEnvFile(t, ".prod.env", `A=prod`)
+	createEnvFile(t, ".staging.env", `A=staging`)
+
+	loadAll(t)
+}
+
+func TestToProtectedEnv(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupProtected(t)
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("version", "")
+
+	// tests don't run in a terminal, so there's nobody to ask
+	err := toCmd.RunE(toCmd, []string{"prod"})
+	if err == nil || !contains(err.Error(), "pass --yes-i-mean prod") {
+		t.Fatalf("expected a protected env error, got %v", err)
+	}
+	if _, err := os.Stat(".env"); !os.IsNotExist(err) {
+		t.Error(".env should not be written for an unconfirmed protected env")
+	}
+
+	defer toCmd.Flags().Set("yes-i-mean", "")
+	toCmd.Flags().Set("yes-i-mean", "dev")
+	if err := toCmd.RunE(toCmd, []string{"prod"}); err == nil {
+		t.Error("confirming another env should not unlock prod")
+	}
+
+	toCmd.Flags().Set("yes-i-mean", "prod")
+	if err := toCmd.RunE(toCmd, []string{"prod"}); err != nil {
+		t.Fatalf("to with --yes-i-mean prod failed: %v", err)
+	}
+
+	// unprotected envs and dry runs need no confirmation
+	toCmd.Flags().Set("yes-i-mean", "")
+	if err := toCmd.RunE(toCmd, []string{"dev"}); err != nil {
+		t.Errorf("to dev failed: %v", err)
+	}
+	runDryRun(t, "prod", false, false)
+
+	// revealing prod's values needs the same confirmation as writing them
+	toCmd.Flags().Set("dry-run", "true")
+	toCmd.Flags().Set("reveal", "true")
+	defer toCmd.Flags().Set("dry-run", "false")
+	defer toCmd.Flags().Set("reveal", "false")
+	output, err := captureOutput(func() error {
+		return toCmd.RunE(toCmd, []string{"prod"})
+	})
+	if err == nil || contains(output, "prod") {
+		t.Errorf("dry run --reveal of prod should need confirmation, got %v:\n%s", err, output)
+	}
+}
+
+func TestSpitProtectedEnv(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupProtected(t)
+
+	spitCmd := cmd.GetSpitCmd()
+	spitCmd.Flags().Set("version", "")
+	defer spitCmd.Flags().Set("env", "*")
+	defer spitCmd.Flags().Set("yes-i-mean", "")
+
+	for _, env := range []string{"prod", "*"} {
+		spitCmd.Flags().Set("env", env)
+		if err := spitCmd.RunE(spitCmd, []string{}); err == nil {
+			t.Errorf("spit --env %s should need confirmation for prod", env)
+		}
+	}
+	if _, err := os.Stat(".prod.env"); !os.IsNotExist(err) {
+		t.Error(".prod.env should not be written without confirmation")
+	}
+
+	spitCmd.Flags().Set("env", "prod")
+	spitCmd.Flags().Set("yes-i-mean", "prod")
+	if err := spitCmd.RunE(spitCmd, []string{}); err != nil {
+		t.Fatalf("spit with --yes-i-mean prod failed: %v", err)
+	}
+	if _, err := os.Stat(".prod.env"); err != nil {
+		t.Error(".prod.env should be written once confirmed")
+	}
+
+	// several protected envs are confirmed together
+	spitCmd.Flags().Set("env", "*")
+	if err := spitCmd.RunE(spitCmd, []string{}); err == nil {
+		t.Error("confirming prod should not unlock staging")
+	}
+	spitCmd.Flags().Set("yes-i-mean", "prod,staging")
+	if err := spitCmd.RunE(spitCmd, []string{}); err != nil {
+		t.Fatalf("spit --env '*' with --yes-i-mean prod,staging failed: %v", err)
+	}
+	if _, err := os.Stat(".staging.env"); err != nil {
+		t.Error(".staging.env should be written once confirmed")
+	}
+}
+
+func TestExecProtectedEnv(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupProtected(t)
+
+	execCmd := cmd.GetExecCmd()
+	if err := execCmd.RunE(execCmd, []string{"prod", "true"}); err == nil || !contains(err.Error(), "pass --yes-i-mean prod") {
+		t.Fatalf("exec with prod should need confirmation, got %v", err)
+	}
+
+	execCmd.Flags().Set("yes-i-mean", "prod")
+	defer execCmd.Flags().Set("yes-i-mean", "")
+	if err := execCmd.RunE(execCmd, []string{"prod", "sh", "-c", `test "$A" = prod`}); err != nil {
+		t.Errorf("exec with --yes-i-mean prod failed: %v", err)
+	}
+}
+
+func TestExportProtectedEnv(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupProtected(t)
+
+	exportCmd := cmd.GetExportCmd()
+	exportCmd.Flags().Set("env", "prod")
+	exportCmd.Flags().Set("format", "json")
+	exportCmd.Flags().Set("output", "")
+	defer exportCmd.Flags().Set("env", "")
+
+	output, err := captureOutput(func() error {
+		return exportCmd.RunE(exportCmd, []string{})
+	})
+	if err == nil || contains(output, "prod") {
+		t.Fatalf("export of prod should need confirmation, got %v:\n%s", err, output)
+	}
+
+	exportCmd.Flags().Set("yes-i-mean", "prod")
+	defer exportCmd.Flags().Set("yes-i-mean", "")
+	output, err = captureOutput(func() error {
+		return exportCmd.RunE(exportCmd, []string{})
+	})
+	if err != nil || !contains(output, `"A": "prod"`) {
+		t.Errorf("export with --yes-i-mean prod failed: %v:\n%s", err, output)
+	}
+}
+
+func TestExplainRevealProtectedEnv(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupProtected(t)
+
+	explainCmd := cmd.GetExplainCmd()
+	explain := func() (string, error) {
+		return captureOutput(func() error {
+			return explainCmd.RunE(explainCmd, []string{"prod"})
+		})
+	}
+
+	// masked values need no confirmation
+	if _, err := explain(); err != nil {
+		t.Fatalf("explain prod failed: %v", err)
+	}
+
+	explainCmd.Flags().Set("reveal", "true")
+	defer explainCmd.Flags().Set("reveal", "false")
+	if output, err := explain(); err == nil || contains(output, "A=prod") {
+		t.Fatalf("explain --reveal of prod should need confirmation, got %v:\n%s", err, output)
+	}
+
+	explainCmd.Flags().Set("yes-i-mean", "prod")
+	defer explainCmd.Flags().Set("yes-i-mean", "")
+	if output, err := explain(); err != nil || !contains(output, "A=prod") {
+		t.Errorf("explain --reveal with --yes-i-mean prod failed: %v:\n%s", err, output)
+	}
+}
+
+func TestVersionDiffRevealProtectedEnv(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupProtected(t)
+
+	createEnvFile(t, ".prod.env", `A=prod2`)
+	loadAll(t)
+
+	diffCmd := cmd.GetVersionDiffCmd()
+	diff := func() (string, error) {
+		return captureOutput(func() error {
+			return diffCmd.RunE(diffCmd, []string{"1", "2"})
+		})
+	}
+
+	if _, err := diff(); err != nil {
+		t.Fatalf("masked version diff failed: %v", err)
+	}
+
+	diffCmd.Flags().Set("reveal", "true")
+	defer diffCmd.Flags().Set("reveal", "false")
+	if output, err := diff(); err == nil || contains(output, "prod2") {
+		t.Fatalf("version diff --reveal of prod should need confirmation, got %v:\n%s", err, output)
+	}
+
+	diffCmd.Flags().Set("yes-i-mean", "prod")
+	defer diffCmd.Flags().Set("yes-i-mean", "")
+	if output, err := diff(); err != nil || !contains(output, "prod → prod2") {
+		t.Errorf("version diff --reveal with --yes-i-mean prod failed: %v:\n%s", err, output)
+	}
+}
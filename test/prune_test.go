@@ -0,0 +1,153 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/reduan2660/swapenv/cmd"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+// writeVersionsAt creates test-project versions 1..n created at the given times
+func writeVersionsAt(t *testing.T, created []time.Time) {
+	t.Helper()
+
+	err := filehandler.UpsertProjectDir(types.ProjectDir{
+		ProjectName:  "test-project",
+		LocalPath:    testProjectDir,
+		VersionNames: make(map[string]string),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, at := range created {
+		project := types.Project{
+			Id:        "id",
+			Name:      "test-project",
+			CreatedAt: at.Unix(),
+			Envs:      map[string][]types.EnvValue{"dev": {{Key: "V", Val: "x", Order: 1}}},
+		}
+		data, err := project.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := filehandler.StoreVersion("test-project", data); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, filehandler.ProjectConfigFile, `retention:
+  keep_last: 1
+  keep_within: 7d
+  keep_weekly: 3mo
+`)
+
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local) // thursday
+	writeVersionsAt(t, []time.Time{
+		time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local),   // v1 older than every window
+		time.Date(2026, 8, 12, 10, 0, 0, 0, time.Local),  // v2 same week as v3
+		time.Date(2026, 8, 13, 10, 0, 0, 0, time.Local),  // v3 newest of its week
+		time.Date(2026, 10, 5, 10, 0, 0, 0, time.Local),  // v4 newest of its week
+		time.Date(2026, 10, 13, 10, 0, 0, 0, time.Local), // v5 within 7d
+		time.Date(2026, 10, 15, 11, 0, 0, 0, time.Local), // v6 current
+	})
+	if err := filehandler.RenameVersion("test-project", 1, "baseline"); err != nil {
+		t.Fatal(err)
+	}
+
+	decisions, err := filehandler.PlanPrune("test-project", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[int]string{
+		1: "named 'baseline'",
+		2: "",
+		3: "weekly within 3mo",
+		4: "weekly within 3mo",
+		5: "within 7d",
+		6: "current",
+	}
+	for _, d := range decisions {
+		want := expected[d.Version]
+		if d.Keep != (want != "") || d.Reason != want {
+			t.Errorf("v%d: expected keep=%v reason %q, got keep=%v reason %q", d.Version, want != "", want, d.Keep, d.Reason)
+		}
+	}
+}
+
+func TestVersionPruneDryRun(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, filehandler.ProjectConfigFile, "max_versions: 2\n")
+
+	old := time.Now().Add(-48 * time.Hour)
+	writeVersionsAt(t, []time.Time{old, old, old, old})
+
+	pruneCmd := cmd.GetVersionPruneCmd()
+	pruneCmd.Flags().Set("dry-run", "true")
+	output, err := captureOutput(func() error {
+		return pruneCmd.RunE(pruneCmd, []string{})
+	})
+	if err != nil {
+		t.Fatalf("prune --dry-run failed: %v", err)
+	}
+	if !contains(output, "delete  v1") || !contains(output, "would delete 2 of 4 versions") {
+		t.Errorf("unexpected dry run output:\n%s", output)
+	}
+	if _, err := os.Stat(filepath.Join(testHomeDir, "test-project", "v1.json")); err != nil {
+		t.Error("dry run should not delete versions")
+	}
+
+	pruneCmd.Flags().Set("dry-run", "false")
+	if err := pruneCmd.RunE(pruneCmd, []string{}); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+
+	versions, err := filehandler.ListVersions("test-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0] != 3 {
+		t.Errorf("expected v3 and v4 to remain, got %v", versions)
+	}
+}
+
+func TestRetentionInvalidWindow(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, filehandler.ProjectConfigFile, "retention:\n  keep_within: soon\n")
+	writeVersionsAt(t, []time.Time{time.Now()})
+
+	if _, err := filehandler.PlanPrune("test-project", time.Now()); err == nil {
+		t.Error("an invalid retention window should return an error")
+	}
+}
+
+func TestLoadSucceedsWhenPruneFails(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".swapenv.yaml", "retention:\n  keep_within: soon\n")
+	createEnvFile(t, ".dev.env", `ENV_1=dev`)
+
+	loadAll(t)
+
+	if values := readStoredEnv(t, "dev"); values["ENV_1"] != "dev" {
+		t.Errorf("dev should be stored, got %v", values)
+	}
+	if _, err := os.Stat(".dev.env"); !os.IsNotExist(err) {
+		t.Error(".dev.env should be deleted after a successful load")
+	}
+}
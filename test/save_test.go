@@ -0,0 +1,163 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+func runSave(t *testing.T, message string) string {
+	t.Helper()
+
+	saveCmd := cmd.GetSaveCmd()
+	saveCmd.Flags().Set("message", message)
+	defer saveCmd.Flags().Set("message", "")
+
+	output, err := captureOutput(func() error {
+		return saveCmd.RunE(saveCmd, []string{})
+	})
+	if err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	return output
+}
+
+func TestSave(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupDrift(t)
+
+	// drop a key too
+	createEnvFile(t, ".env", `B=edited
+URL=http://dev
+SHARED=common
+NEW=added`)
+
+	output := runSave(t, "debugging session")
+	if !contains(output, "saved .env into dev as v2 (1 added, 2 changed, 1 removed)") {
+		t.Errorf("unexpected save output: %s", output)
+	}
+
+	dev := readStoredEnv(t, "dev")
+	if _, ok := dev["A"]; ok {
+		t.Error("A was removed from .env and should be removed from dev")
+	}
+	// URL referenced the removed A, its working value is kept instead
+	if dev["B"] != "edited" || dev["NEW"] != "added" || dev["URL"] != "http://dev" {
+		t.Errorf("unexpected dev after save: %v", dev)
+	}
+	if _, ok := dev["SHARED"]; ok {
+		t.Error("keys from common should not be saved into dev")
+	}
+	if prod := readStoredEnv(t, "prod"); prod["A"] != "prod" {
+		t.Errorf("other envs should be carried over, got %v", prod)
+	}
+
+	header, err := filehandler.ReadProjectHeader(filepath.Join(testHomeDir, "test-project", "v2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Operation != types.OpEdit || header.Message != "debugging session" {
+		t.Errorf("unexpected header: %+v", header)
+	}
+
+	// saved edits are no longer drift
+	toCmd := cmd.GetToCmd()
+	if err := toCmd.RunE(toCmd, []string{"prod"}); err != nil {
+		t.Errorf("to after save failed: %v", err)
+	}
+}
+
+func TestSaveKeepsReferences(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupDrift(t)
+
+	runSave(t, "")
+
+	dev := readStoredEnv(t, "dev")
+	if dev["URL"] != "http://${A}" {
+		t.Errorf("URL still expands to its working value and should keep its reference, got %v", dev)
+	}
+
+	// saving reproduces the file
+	replaceTo(t, "dev")
+	if content := readDotEnv(t); !contains(content, "URL=http://dev") {
+		t.Errorf("expected URL=http://dev after swapping back, got:\n%s", content)
+	}
+}
+
+func TestSaveKeepsLiteralValues(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupDrift(t)
+
+	createEnvFile(t, ".env", `A=dev
+B=dev
+URL=http://dev
+SHARED=common
+TEMPLATE='hello ${A}'`)
+	runSave(t, "")
+
+	replaceTo(t, "dev")
+	if content := readDotEnv(t); !contains(content, "TEMPLATE='hello ${A}'") {
+		t.Errorf("TEMPLATE should stay literal after swapping back, got:\n%s", content)
+	}
+
+	if output := runSave(t, ""); !contains(output, "nothing to save") {
+		t.Errorf("expected nothing to save, got: %s", output)
+	}
+}
+
+func TestSaveIgnoresKeysKeptFromPreviousEnv(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".dev.env", `A=dev`)
+	createEnvFile(t, ".prod.env", "A=prod\nPROD_SECRET=s3cret")
+	loadAll(t)
+
+	swapTo(t, "prod")
+	swapTo(t, "dev")
+
+	if output := runSave(t, ""); !contains(output, "nothing to save") {
+		t.Errorf("keys kept from prod are not edits to dev, got: %s", output)
+	}
+
+	// a key really added after the swap is saved, the kept one still isn't
+	createEnvFile(t, ".env", readDotEnv(t)+"\nNEW=added")
+	runSave(t, "")
+
+	dev := readStoredEnv(t, "dev")
+	if dev["NEW"] != "added" {
+		t.Errorf("NEW should be saved into dev, got %v", dev)
+	}
+	if _, ok := dev["PROD_SECRET"]; ok {
+		t.Errorf("PROD_SECRET should not be saved into dev, got %v", dev)
+	}
+}
+
+func TestSaveNothingChanged(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupDrift(t)
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("force", "true")
+	defer toCmd.Flags().Set("force", "false")
+	if err := toCmd.RunE(toCmd, []string{"dev"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if output := runSave(t, ""); !contains(output, "nothing to save") {
+		t.Errorf("expected nothing to save, got: %s", output)
+	}
+
+	if _, err := os.Stat(filepath.Join(testHomeDir, "test-project", "v2.json")); !os.IsNotExist(err) {
+		t.Error("save without changes should not create a version")
+	}
+}
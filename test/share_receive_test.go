@@ -160,27 +160,15 @@ func TestSaveReceivedNewProject(t *testing.T) {
 
 	// Create project (simulating saveReceived logic)
 	newProject := types.ProjectDir{
-		ProjectName:    projectName,
-		LocalPath:      "", // No local path for received projects
-		CurrentEnv:     "",
-		CurrentVersion: 1,
-		LatestVersion:  1,
-		VersionNames:   make(map[string]string),
+		ProjectName:  projectName,
+		LocalPath:    "", // No local path for received projects
+		CurrentEnv:   "",
+		VersionNames: make(map[string]string),
 	}
 	if err := filehandler.UpsertProjectDir(newProject); err != nil {
 		t.Fatalf("failed to create project: %v", err)
 	}
 
-	versionPath, err := filehandler.GetVersionFilePath(projectName, 1)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	homeDir, err := filehandler.GetHomeDirectory(projectName)
-	if err != nil {
-		t.Fatal(err)
-	}
-
 	now := time.Now().UTC().Unix()
 	projectData := types.Project{
 		Id:             uuid.New().String(),
@@ -197,10 +185,16 @@ func TestSaveReceivedNewProject(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := filehandler.WriteProject(homeDir, versionPath, projectJSON); err != nil {
+	version, err := filehandler.StoreVersion(projectName, projectJSON)
+	if err != nil {
 		t.Fatalf("failed to write project: %v", err)
 	}
 
+	versionPath, err := filehandler.GetVersionFilePath(projectName, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	// Verify project was created
 	project, err := filehandler.FindProjectByName(projectName)
 	if err != nil {
@@ -251,32 +245,13 @@ func TestSaveReceivedExistingProject(t *testing.T) {
 		t.Fatalf("expected initial version 1, got %d", project.CurrentVersion)
 	}
 
-	// Simulate receiving new version (bumping version)
-	newVersion, err := filehandler.BumpVersion(projectName)
-	if err != nil {
-		t.Fatalf("failed to bump version: %v", err)
-	}
-	if newVersion != 2 {
-		t.Errorf("expected bumped version 2, got %d", newVersion)
-	}
-
-	// Save received envs to new version
+	// Simulate receiving new version
 	envMap := map[string][]types.EnvValue{
 		"dev": {
 			{Key: "ENV_1", Val: "v2_received"},
 		},
 	}
 
-	versionPath, err := filehandler.GetVersionFilePath(projectName, newVersion)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	homeDir, err := filehandler.GetHomeDirectory(projectName)
-	if err != nil {
-		t.Fatal(err)
-	}
-
 	now := time.Now().UTC().Unix()
 	projectData := types.Project{
 		Id:             uuid.New().String(),
@@ -289,8 +264,12 @@ func TestSaveReceivedExistingProject(t *testing.T) {
 	}
 
 	projectJSON, _ := projectData.MarshalJSON()
-	if err := filehandler.WriteProject(homeDir, versionPath, projectJSON); err != nil {
-		t.Fatal(err)
+	newVersion, err := filehandler.StoreVersion(projectName, projectJSON)
+	if err != nil {
+		t.Fatalf("failed to store version: %v", err)
+	}
+	if newVersion != 2 {
+		t.Errorf("expected bumped version 2, got %d", newVersion)
 	}
 
 	// Verify version was bumped
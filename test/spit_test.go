@@ -157,3 +157,65 @@ func TestSpitSpecificEnv(t *testing.T) {
 		t.Error(".prod.env should NOT exist after spit --env dev")
 	}
 }
+
+func TestSpitPreservesComments(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	devEnvContent := `# Application settings
+APP_NAME=swapenv # shown in the UI
+
+# ---- Database ----
+
+# primary connection
+DB_HOST=localhost
+DB_PORT=5432 # default port
+
+
+# Feature flags
+FLAG_A=true
+# FLAG_B=false
+# end of file`
+	createEnvFile(t, ".dev.env", devEnvContent)
+
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("env", "*")
+	loadCmd.Flags().Set("replace", "false")
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	spitCmd := cmd.GetSpitCmd()
+	spitCmd.Flags().Set("env", "dev")
+	spitCmd.Flags().Set("version", "")
+	if err := spitCmd.RunE(spitCmd, []string{}); err != nil {
+		t.Fatalf("spit failed: %v", err)
+	}
+
+	content, err := os.ReadFile(".dev.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != devEnvContent {
+		t.Errorf("spit should reproduce the loaded file\nexpected:\n%s\ngot:\n%s", devEnvContent, string(content))
+	}
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("replace", "true")
+	toCmd.Flags().Set("version", "")
+	if err := toCmd.RunE(toCmd, []string{"dev"}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err = os.ReadFile(".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contentStr := string(content)
+	if !contains(contentStr, "# ---- Database ----\n\n# primary connection\nDB_HOST=localhost") {
+		t.Errorf("section header should be preserved in .env, got:\n%s", contentStr)
+	}
+	if !contains(contentStr, "DB_PORT=5432 # default port") {
+		t.Errorf("inline comment should be preserved in .env, got:\n%s", contentStr)
+	}
+}
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+)
+
+// setupDrift swaps to dev and then edits .env by hand
+func setupDrift(t *testing.T) {
+	t.Helper()
+
+	createEnvFile(t, ".common.env", `SHARED=common`)
+	createEnvFile(t, ".dev.env", `A=dev
+B=dev
+URL=http://${A}`)
+	createEnvFile(t, ".prod.env", `A=prod`)
+
+	loadAll(t)
+
+	replaceTo(t, "dev")
+
+	createEnvFile(t, ".env", `A=dev
+B=edited
+URL=http://dev
+SHARED=common
+NEW=added`)
+}
+
+func runStatus(t *testing.T, reveal bool) string {
+	t.Helper()
+
+	statusCmd := cmd.GetStatusCmd()
+	statusCmd.Flags().Set("reveal", boolString(reveal))
+	defer statusCmd.Flags().Set("reveal", "false")
+
+	output, err := captureOutput(func() error {
+		return statusCmd.RunE(statusCmd, []string{})
+	})
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	return output
+}
+
+func TestStatus(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupDrift(t)
+
+	output := runStatus(t, false)
+	for _, want := range []string{"on dev (v1)", "+ NEW=********", "~ B: ******** → ********", "was edited since the last swap"} {
+		if !contains(output, want) {
+			t.Errorf("expected %q in status, got:\n%s", want, output)
+		}
+	}
+	if contains(output, "URL") || contains(output, "SHARED") {
+		t.Errorf("unchanged keys should not be listed, got:\n%s", output)
+	}
+
+	output = runStatus(t, true)
+	if !contains(output, "~ B: dev → edited") {
+		t.Errorf("expected revealed change, got:\n%s", output)
+	}
+}
+
+func TestStatusClean(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupDrift(t)
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("force", "true")
+	defer toCmd.Flags().Set("force", "false")
+	if err := toCmd.RunE(toCmd, []string{"dev"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if output := runStatus(t, false); !contains(output, ".env matches dev") {
+		t.Errorf("expected clean status, got:\n%s", output)
+	}
+}
+
+func TestStatusCleanAfterSwapBack(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".dev.env", `A=dev`)
+	createEnvFile(t, ".prod.env", "A=prod\nPROD_SECRET=s3cret")
+	loadAll(t)
+
+	swapTo(t, "prod")
+	swapTo(t, "dev")
+
+	output := runStatus(t, false)
+	if !contains(output, ".env matches dev") || contains(output, "PROD_SECRET") {
+		t.Errorf("keys kept from prod should not show up, got:\n%s", output)
+	}
+}
+
+func TestToRefusesDriftedEnv(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupDrift(t)
+
+	toCmd := cmd.GetToCmd()
+	if err := toCmd.RunE(toCmd, []string{"prod"}); err == nil {
+		t.Fatal("to should refuse to overwrite an edited .env")
+	}
+
+	content, _ := os.ReadFile(".env")
+	if !contains(string(content), "B=edited") {
+		t.Error(".env should be left untouched")
+	}
+
+	toCmd.Flags().Set("force", "true")
+	defer toCmd.Flags().Set("force", "false")
+	if err := toCmd.RunE(toCmd, []string{"prod"}); err != nil {
+		t.Fatalf("to --force failed: %v", err)
+	}
+
+	content, _ = os.ReadFile(".env")
+	if !contains(string(content), "A=prod") {
+		t.Error(".env should be overwritten with --force")
+	}
+}
+
+func TestToSavesDriftedEnv(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupDrift(t)
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("save", "true")
+	defer toCmd.Flags().Set("save", "false")
+	if err := toCmd.RunE(toCmd, []string{"prod"}); err != nil {
+		t.Fatalf("to --save failed: %v", err)
+	}
+
+	dev := readStoredEnv(t, "dev")
+	if dev["B"] != "edited" || dev["NEW"] != "added" {
+		t.Errorf("edits should be saved into dev, got %v", dev)
+	}
+	if dev["URL"] != "http://${A}" {
+		t.Errorf("unchanged values should keep their references, got URL=%q", dev["URL"])
+	}
+	if _, ok := dev["SHARED"]; ok {
+		t.Error("keys from common should not be saved into dev")
+	}
+}
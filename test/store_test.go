@@ -0,0 +1,282 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+	"github.com/reduan2660/swapenv/internal/filehandler"
+	"github.com/reduan2660/swapenv/internal/types"
+)
+
+func TestConcurrentMapUpdates(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	const workers = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- filehandler.UpsertProjectDir(types.ProjectDir{
+				ProjectName:  fmt.Sprintf("project-%d", i),
+				LocalPath:    fmt.Sprintf("/tmp/project-%d", i),
+				VersionNames: make(map[string]string),
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dirs, err := filehandler.ReadProjectDirs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) != workers {
+		t.Errorf("expected %d projects after concurrent upserts, got %d", workers, len(dirs))
+	}
+}
+
+func TestMapRecoversFromBackup(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	for _, name := range []string{"first", "second"} {
+		if err := filehandler.UpsertProjectDir(types.ProjectDir{ProjectName: name, LocalPath: "/tmp/" + name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mapPath, err := filehandler.GetMapFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(mapPath + ".bak"); err != nil {
+		t.Fatalf("map.json.bak should exist after an update: %v", err)
+	}
+
+	// simulate a write interrupted by an older swapenv
+	if err := os.WriteFile(mapPath, []byte(`[{"ProjectName": "fir`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := filehandler.FindProjectByName("first")
+	if err != nil {
+		t.Fatalf("reading a truncated map should fall back to the backup: %v", err)
+	}
+	if dir == nil {
+		t.Fatal("project from backup should be found")
+	}
+
+	// the next update repairs map.json
+	if err := filehandler.UpdateCurrentEnv("first", "dev"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(mapPath + ".bak"); err != nil {
+		t.Fatal(err)
+	}
+	dir, err = filehandler.FindProjectByName("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir == nil || dir.CurrentEnv != "dev" {
+		t.Errorf("map.json should be rewritten after recovery, got %+v", dir)
+	}
+}
+
+func TestAtomicWriteLeavesNoTempFiles(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	baseDir, err := filehandler.GetBaseDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(baseDir, "file.json")
+	for i := 0; i < 3; i++ {
+		if err := filehandler.WriteFileAtomic(target, []byte(fmt.Sprintf(`{"n": %d}`, i)), 0644, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if contains(entry.Name(), "tmp") {
+			t.Errorf("temp file left behind: %s", entry.Name())
+		}
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"n": 2}` {
+		t.Errorf("unexpected content: %s", data)
+	}
+	backup, err := os.ReadFile(target + ".bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(backup) != `{"n": 1}` {
+		t.Errorf("backup should hold the previous content, got: %s", backup)
+	}
+}
+
+func loadSecretProject(t *testing.T) string {
+	t.Helper()
+
+	createEnvFile(t, ".dev.env", `SECRET=hunter2`)
+	loadAll(t)
+
+	return filepath.Join(testHomeDir, "test-project", "v1.json")
+}
+
+func TestStoreEncryptDecrypt(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	t.Setenv("SWAPENV_PASSPHRASE", "correct horse")
+
+	v1Path := loadSecretProject(t)
+
+	info, err := os.Stat(v1Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("version files should be 0600, got %v", info.Mode().Perm())
+	}
+
+	encryptCmd := cmd.GetStoreEncryptCmd()
+	if err := encryptCmd.RunE(encryptCmd, []string{}); err != nil {
+		t.Fatalf("store encrypt failed: %v", err)
+	}
+
+	raw, err := os.ReadFile(v1Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !filehandler.IsSealed(raw) || contains(string(raw), "hunter2") {
+		t.Fatal("version file should be sealed after store encrypt")
+	}
+
+	// reads decrypt transparently
+	replaceTo(t, "dev")
+	content, err := os.ReadFile(".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(string(content), "SECRET=hunter2") {
+		t.Errorf(".env should hold the decrypted value, got:\n%s", content)
+	}
+
+	// new versions are sealed on write
+	createEnvFile(t, ".dev.env", `SECRET=rotated`)
+	loadAll(t)
+	raw, err = os.ReadFile(filepath.Join(testHomeDir, "test-project", "v2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !filehandler.IsSealed(raw) {
+		t.Error("versions written to an encrypted store should be sealed")
+	}
+
+	decryptCmd := cmd.GetStoreDecryptCmd()
+	if err := decryptCmd.RunE(decryptCmd, []string{}); err != nil {
+		t.Fatalf("store decrypt failed: %v", err)
+	}
+
+	raw, err = os.ReadFile(v1Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filehandler.IsSealed(raw) || !contains(string(raw), "hunter2") {
+		t.Error("version file should be plaintext after store decrypt")
+	}
+	if _, err := os.Stat(filepath.Join(testHomeDir, "store.json")); !os.IsNotExist(err) {
+		t.Error("store.json should be removed after store decrypt")
+	}
+}
+
+func TestStoreEncryptWithKeyFile(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	keyFile := filepath.Join(t.TempDir(), "swapenv.key")
+	if err := os.WriteFile(keyFile, []byte("0123456789abcdef0123456789abcdef\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SWAPENV_KEY_FILE", keyFile)
+
+	loadSecretProject(t)
+
+	encryptCmd := cmd.GetStoreEncryptCmd()
+	if err := encryptCmd.RunE(encryptCmd, []string{}); err != nil {
+		t.Fatalf("store encrypt failed: %v", err)
+	}
+
+	envs, err := filehandler.ListProjectEnv(filepath.Join(testHomeDir, "test-project", "v1.json"))
+	if err != nil {
+		t.Fatalf("listing envs of a sealed version failed: %v", err)
+	}
+	if len(envs) != 1 || envs[0] != "dev" {
+		t.Errorf("expected [dev], got %v", envs)
+	}
+
+	output, err := captureOutput(func() error {
+		return cmd.GetStoreCmd().RunE(cmd.GetStoreCmd(), []string{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(output, "encrypted") || !contains(output, "1 sealed, 0 plaintext") {
+		t.Errorf("unexpected status output: %s", output)
+	}
+}
+
+func TestFailedVersionWriteKeepsMap(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".dev.env", `ENV_1=dev`)
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("env", "*")
+	loadCmd.Flags().Set("replace", "false")
+	loadCmd.Flags().Set("keep", "true")
+	defer loadCmd.Flags().Set("keep", "false")
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a directory in the way makes writing v2.json fail
+	blocker := filepath.Join(testHomeDir, "test-project", "v2.json")
+	if err := os.MkdirAll(filepath.Join(blocker, "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadCmd.RunE(loadCmd, []string{}); err == nil {
+		t.Fatal("load should fail when the version file can't be written")
+	}
+
+	dir, err := filehandler.FindProjectByName("test-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir.LatestVersion != 1 || dir.CurrentVersion != 1 {
+		t.Errorf("map should still point at v1, got current v%d latest v%d", dir.CurrentVersion, dir.LatestVersion)
+	}
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/reduan2660/swapenv/cmd"
+)
+
+func setupTargets(t *testing.T) {
+	t.Helper()
+
+	createEnvFile(t, ".swapenv.yaml", `targets:
+  - path: .env.local
+  - path: apps/api/.env
+    prefix: API_
+    strip_prefix: true
+  - path: apps/web/.env
+    prefix: WEB_
+    rename:
+      - from: PUBLIC_URL
+        to: NEXT_PUBLIC_URL
+`)
+	createEnvFile(t, ".dev.env", `API_PORT=8080
+API_DB=postgres://dev
+WEB_THEME=dark
+PUBLIC_URL=http://localhost`)
+
+	loadAll(t)
+
+	swapTo(t, "dev")
+}
+
+func TestToTargets(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupTargets(t)
+
+	for path, want := range map[string]string{
+		".env.local":    "API_PORT=8080\nAPI_DB=postgres://dev\nWEB_THEME=dark\nPUBLIC_URL=http://localhost",
+		"apps/api/.env": "PORT=8080\nDB=postgres://dev",
+		"apps/web/.env": "WEB_THEME=dark\nNEXT_PUBLIC_URL=http://localhost",
+	} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s should be written: %v", path, err)
+		}
+		if string(content) != want {
+			t.Errorf("%s:\n%s\nwant:\n%s", path, content, want)
+		}
+	}
+
+	if _, err := os.Stat(".env"); !os.IsNotExist(err) {
+		t.Error(".env is not a target and should not be written")
+	}
+}
+
+func TestSaveTargets(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+	setupTargets(t)
+
+	createEnvFile(t, "apps/api/.env", "PORT=9090\nDB=postgres://dev")
+	createEnvFile(t, "apps/web/.env", "WEB_THEME=dark\nNEXT_PUBLIC_URL=https://dev.example.com")
+
+	toCmd := cmd.GetToCmd()
+	if err := toCmd.RunE(toCmd, []string{"dev"}); err == nil {
+		t.Fatal("to should refuse to overwrite edited targets")
+	}
+
+	output := runStatus(t, true)
+	for _, want := range []string{"apps/api/.env differs from dev", "~ PORT: 8080 → 9090", "~ NEXT_PUBLIC_URL: http://localhost → https://dev.example.com", ".env.local matches dev"} {
+		if !contains(output, want) {
+			t.Errorf("expected %q in status, got:\n%s", want, output)
+		}
+	}
+
+	runSave(t, "")
+
+	dev := readStoredEnv(t, "dev")
+	if dev["API_PORT"] != "9090" || dev["PUBLIC_URL"] != "https://dev.example.com" {
+		t.Errorf("target edits should be saved under the stored keys, got %v", dev)
+	}
+	if _, ok := dev["PORT"]; ok {
+		t.Error("target keys should be mapped back to stored keys")
+	}
+}
+
+func TestTargetsStayInProject(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	createEnvFile(t, ".dev.env", `A=dev`)
+	loadAll(t)
+
+	outside := filepath.Dir(testProjectDir)
+	victim := filepath.Join(outside, "victimrc")
+	createEnvFile(t, victim, "export PATH=/usr/bin")
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("version", "")
+	for _, path := range []string{"../victimrc", filepath.ToSlash(victim)} {
+		createEnvFile(t, ".swapenv.yaml", "targets:\n  - path: "+path+"\n")
+		if err := toCmd.RunE(toCmd, []string{"dev"}); err == nil {
+			t.Errorf("target %s reaches outside the project and should be rejected", path)
+		}
+	}
+
+	if err := os.Symlink(outside, "linked"); err != nil {
+		t.Skip("symlinks not supported")
+	}
+	createEnvFile(t, ".swapenv.yaml", "targets:\n  - path: linked/victimrc\n")
+	if err := toCmd.RunE(toCmd, []string{"dev"}); err == nil {
+		t.Error("a target behind a symlink outside the project should be rejected")
+	}
+
+	if content, _ := os.ReadFile(victim); string(content) != "export PATH=/usr/bin" {
+		t.Errorf("files outside the project must not be written, got:\n%s", content)
+	}
+}
@@ -535,3 +535,186 @@ func TestToWithNamedVersion(t *testing.T) {
 		t.Error("ENV_1=v1_value should be in .env when using --version stable")
 	}
 }
+
+func TestVersionRevert(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("env", "*")
+	loadCmd.Flags().Set("replace", "false")
+
+	createEnvFile(t, ".dev.env", `KEY=one`)
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	createEnvFile(t, ".dev.env", `KEY=two`)
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	revertCmd := cmd.GetVersionRevertCmd()
+	revertCmd.Flags().Set("message", "undo rotation")
+	defer revertCmd.Flags().Set("message", "")
+	if err := revertCmd.RunE(revertCmd, []string{"1"}); err != nil {
+		t.Fatalf("version revert failed: %v", err)
+	}
+
+	project, _ := filehandler.FindProjectByLocalPath(testProjectDir)
+	if project.CurrentVersion != 3 || project.LatestVersion != 3 {
+		t.Errorf("revert should create v3 as current and latest, got current %d latest %d", project.CurrentVersion, project.LatestVersion)
+	}
+
+	if values := readStoredEnv(t, "dev"); values["KEY"] != "one" {
+		t.Errorf("v3 should hold v1's values, got %v", values)
+	}
+
+	header, err := filehandler.ReadProjectHeader(filepath.Join(testHomeDir, "test-project", "v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Operation != "revert" || len(header.Source) != 1 || header.Source[0] != "v1" || header.Message != "undo rotation" {
+		t.Errorf("unexpected revert metadata: %+v", header)
+	}
+
+	// history in between is kept
+	if _, err := os.Stat(filepath.Join(testHomeDir, "test-project", "v2.json")); err != nil {
+		t.Error("v2.json should still exist after revert")
+	}
+}
+
+func TestLoadFastForwardsFromCurrentVersion(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("env", "*")
+	loadCmd.Flags().Set("replace", "false")
+
+	createEnvFile(t, ".dev.env", `A=1
+B=1`)
+	createEnvFile(t, ".prod.env", `P=1`)
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	createEnvFile(t, ".dev.env", `A=2`)
+	loadCmd.Flags().Set("replace", "true")
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	loadCmd.Flags().Set("replace", "false")
+
+	// back to v1, then load on top of it
+	if err := filehandler.SetCurrentVersion("test-project", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	createEnvFile(t, ".dev.env", `C=3`)
+	loadCmd.Flags().Set("env", "dev")
+	defer loadCmd.Flags().Set("env", "*")
+	if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	dev := readStoredEnv(t, "dev")
+	if dev["A"] != "1" || dev["B"] != "1" || dev["C"] != "3" {
+		t.Errorf("v3 should fast forward from v1 (the current version), got %v", dev)
+	}
+
+	// envs that weren't loaded are carried forward
+	if prod := readStoredEnv(t, "prod"); prod["P"] != "1" {
+		t.Errorf("prod should be carried forward, got %v", prod)
+	}
+}
+
+func TestEnvVersionRollback(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	loadCmd := cmd.GetLoadCmd()
+	loadCmd.Flags().Set("replace", "false")
+	loadCmd.Flags().Set("env", "*")
+	defer loadCmd.Flags().Set("env", "*")
+
+	load := func(env, content string) {
+		t.Helper()
+		createEnvFile(t, "."+env+".env", content)
+		loadCmd.Flags().Set("env", env)
+		if err := loadCmd.RunE(loadCmd, []string{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	load("dev", `D=1`)  // v1
+	load("prod", `P=1`) // v2
+	load("prod", `P=2`) // v3
+	load("dev", `D=2`)  // v4
+	load("prod", `P=3`) // v5
+
+	rollbackCmd := cmd.GetVersionRollbackCmd()
+	rollbackCmd.Flags().Set("env", "prod")
+	defer rollbackCmd.Flags().Set("env", "")
+	if err := rollbackCmd.RunE(rollbackCmd, []string{}); err != nil {
+		t.Fatalf("rollback --env prod failed: %v", err)
+	}
+
+	project, _ := filehandler.FindProjectByLocalPath(testProjectDir)
+	if project.CurrentVersion != 5 {
+		t.Errorf("rolling back one env should not move the project version, got v%d", project.CurrentVersion)
+	}
+	if project.EnvVersions["prod"] != 3 {
+		t.Errorf("prod should roll back to v3 (its previous change), got %v", project.EnvVersions)
+	}
+
+	toCmd := cmd.GetToCmd()
+	toCmd.Flags().Set("replace", "true")
+	toCmd.Flags().Set("version", "")
+	if err := toCmd.RunE(toCmd, []string{"prod"}); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(".env"); !contains(string(content), "P=2") {
+		t.Errorf("to prod should use the rolled back version, got:\n%s", content)
+	}
+	if err := toCmd.RunE(toCmd, []string{"dev"}); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(".env"); !contains(string(content), "D=2") {
+		t.Errorf("dev should be unaffected by the prod rollback, got:\n%s", content)
+	}
+
+	// steps go through prod's changes, skipping versions where only dev changed
+	if err := rollbackCmd.RunE(rollbackCmd, []string{}); err != nil {
+		t.Fatal(err)
+	}
+	project, _ = filehandler.FindProjectByLocalPath(testProjectDir)
+	if project.EnvVersions["prod"] != 2 {
+		t.Errorf("second rollback should reach v2, got %v", project.EnvVersions)
+	}
+
+	// switching an env to the project version lets it follow the project again
+	versionCmd := cmd.GetVersionCmd()
+	versionCmd.Flags().Set("env", "prod")
+	defer versionCmd.Flags().Set("env", "")
+	if err := versionCmd.RunE(versionCmd, []string{"latest"}); err != nil {
+		t.Fatal(err)
+	}
+	project, _ = filehandler.FindProjectByLocalPath(testProjectDir)
+	if _, pinned := project.EnvVersions["prod"]; pinned {
+		t.Errorf("prod should follow the project after switching to the current version, got %v", project.EnvVersions)
+	}
+
+	// loading an env clears its pin
+	if err := versionCmd.RunE(versionCmd, []string{"2"}); err != nil {
+		t.Fatal(err)
+	}
+	load("prod", `EXTRA=1`)
+	project, _ = filehandler.FindProjectByLocalPath(testProjectDir)
+	if len(project.EnvVersions) != 0 {
+		t.Errorf("loading prod should clear its pin, got %v", project.EnvVersions)
+	}
+	prod := readStoredEnv(t, "prod")
+	if prod["P"] != "1" || prod["EXTRA"] != "1" {
+		t.Errorf("load should fast forward from prod's pinned version, got %v", prod)
+	}
+}